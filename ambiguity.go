@@ -0,0 +1,131 @@
+package httprouterpersist
+
+import (
+	"strings"
+)
+
+/*
+The AmbiguityWarning type reports two routes with the same method that can
+both match Example.
+*/
+type AmbiguityWarning struct {
+	Method  string
+	First   string
+	Second  string
+	Example string
+}
+
+/*
+Reports the pairs of routes with the same method whose patterns can both
+match some concrete path, such as "/:a/b" and "/x/:b", which both match
+"/x/b". httprouter panics when the second route of such a pair is
+registered, so the check is run on candidate routes before registering them:
+each candidate is compared with the other candidates and with the routes
+already registered on the router.
+
+	routes := []router.Route{
+		{Method: "GET", Path: "/:tenant/settings"},
+		{Method: "GET", Path: "/admin/:page"},
+	}
+	if warnings := r.CheckAmbiguity(routes...); len(warnings) > 0 {
+		log.Fatalf("ambiguous routes: %v", warnings)
+	}
+
+Returns nil if there are none. Called without candidates it always returns
+nil: since httprouter refuses to register a conflicting route, the routes
+already registered can never be ambiguous with each other.
+*/
+func (r *Router) CheckAmbiguity(candidates ...Route) []AmbiguityWarning {
+	var warnings []AmbiguityWarning
+	check := func(a, b *Route) {
+		if a.Method != b.Method {
+			return
+		}
+		if example, ok := overlap(a.Path, b.Path); ok {
+			warnings = append(warnings, AmbiguityWarning{a.Method, a.Path, b.Path, example})
+		}
+	}
+	for _, rt := range r.routeList() {
+		for i := range candidates {
+			check(rt, &candidates[i])
+		}
+	}
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			check(&candidates[i], &candidates[j])
+		}
+	}
+	return warnings
+}
+
+/*
+Reports whether the patterns a and b both match some path, and returns such
+a path.
+*/
+func overlap(a, b string) (string, bool) {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	example := make([]string, 0, len(as))
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		sa, sb := as[i], bs[i]
+		if strings.HasPrefix(sa, "*") || strings.HasPrefix(sb, "*") {
+			rest := bs[i:]
+			if strings.HasPrefix(sb, "*") {
+				rest = as[i:]
+			}
+			for _, seg := range rest {
+				example = append(example, concreteSegment(seg))
+			}
+			return strings.Join(example, "/"), true
+		}
+		seg, ok := overlapSegment(sa, sb)
+		if !ok {
+			return "", false
+		}
+		example = append(example, seg)
+	}
+	if len(as) != len(bs) {
+		return "", false
+	}
+	return strings.Join(example, "/"), true
+}
+
+/*
+Reports whether the pattern segments a and b both match some segment, and
+returns such a segment. A segment is a static prefix optionally followed by a
+param, which matches any non-empty remainder.
+*/
+func overlapSegment(a, b string) (string, bool) {
+	pa, hasA := staticPrefix(a)
+	pb, hasB := staticPrefix(b)
+	switch {
+	case !hasA && !hasB:
+		return a, a == b
+	case !hasA:
+		return a, strings.HasPrefix(a, pb) && len(a) > len(pb)
+	case !hasB:
+		return b, strings.HasPrefix(b, pa) && len(b) > len(pa)
+	case strings.HasPrefix(pa, pb) || strings.HasPrefix(pb, pa):
+		if len(pb) > len(pa) {
+			pa = pb
+		}
+		return pa + "x", true
+	}
+	return "", false
+}
+
+/*
+Returns the static part of a pattern segment and whether it ends in a param.
+*/
+func staticPrefix(seg string) (string, bool) {
+	if i := strings.IndexByte(seg, ':'); i >= 0 {
+		return seg[:i], true
+	}
+	return seg, false
+}
+
+func concreteSegment(seg string) string {
+	if prefix, isParam := staticPrefix(strings.TrimPrefix(seg, "*")); isParam || strings.HasPrefix(seg, "*") {
+		return prefix + "x"
+	}
+	return seg
+}
@@ -0,0 +1,76 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCheckAmbiguity(t *testing.T) {
+	r := New()
+	ok := func(http.ResponseWriter, *http.Request) {}
+	r.GET("/users/:id", ok)
+	r.GET("/orders", ok)
+
+	warnings := r.CheckAmbiguity(
+		Route{Method: "GET", Path: "/:a/b"},
+		Route{Method: "GET", Path: "/x/:b"},
+		Route{Method: "POST", Path: "/users/new"},
+	)
+	want := []AmbiguityWarning{
+		{"GET", "/users/:id", "/:a/b", "/users/b"},
+		{"GET", "/:a/b", "/x/:b", "/x/b"},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings = %v, want %v", warnings, want)
+	}
+	for i := range want {
+		if warnings[i] != want[i] {
+			t.Errorf("warning %d = %v, want %v", i, warnings[i], want[i])
+		}
+	}
+}
+
+func TestCheckAmbiguityClean(t *testing.T) {
+	r := New()
+	r.GET("/users/:id", func(http.ResponseWriter, *http.Request) {})
+	warnings := r.CheckAmbiguity(
+		Route{Method: "GET", Path: "/users"},
+		Route{Method: "GET", Path: "/orders/:id/items"},
+		Route{Method: "GET", Path: "/files/*path"},
+		Route{Method: "POST", Path: "/users/new"},
+	)
+	if warnings != nil {
+		t.Errorf("warnings = %v, want none", warnings)
+	}
+}
+
+func TestCheckAmbiguityCatchAll(t *testing.T) {
+	warnings := New().CheckAmbiguity(
+		Route{Method: "GET", Path: "/static/*path"},
+		Route{Method: "GET", Path: "/static/css/:file"},
+	)
+	if len(warnings) != 1 || warnings[0].Example != "/static/css/x" {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestCheckAmbiguityPredictsConflicts(t *testing.T) {
+	candidates := []Route{
+		{Method: "GET", Path: "/:tenant/settings"},
+		{Method: "GET", Path: "/admin/:page"},
+	}
+	warnings := New().CheckAmbiguity(candidates...)
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want one", warnings)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering the reported pair did not panic")
+		}
+	}()
+	r := New()
+	for _, c := range candidates {
+		r.Handle(c.Method, c.Path, func(http.ResponseWriter, *http.Request) {})
+	}
+}
@@ -0,0 +1,65 @@
+package httprouterpersist
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type baggageKeyType struct{}
+
+var baggageKey = baggageKeyType{}
+
+/*
+Returns a copy of r with the baggage item key set to val. All baggage items
+of a request live in a single map on its context; the map is copied on write,
+so requests derived earlier are not affected.
+
+	r = router.WithBaggage(r, "tenant", tenantID)
+	...
+	tenant := router.Baggage(r, "tenant")
+*/
+func WithBaggage(r *http.Request, key, val string) *http.Request {
+	old, _ := r.Context().Value(baggageKey).(map[string]string)
+	items := make(map[string]string, len(old)+1)
+	for k, v := range old {
+		items[k] = v
+	}
+	items[key] = val
+	return r.WithContext(context.WithValue(r.Context(), baggageKey, items))
+}
+
+/*
+Returns the baggage item key of r, or an empty string if it is not set.
+*/
+func Baggage(r *http.Request, key string) string {
+	items, _ := r.Context().Value(baggageKey).(map[string]string)
+	return items[key]
+}
+
+/*
+Propagates the baggage items of from to the outbound request out by setting
+its W3C "baggage" header.
+
+	out, _ := http.NewRequest("GET", upstreamURL, nil)
+	router.InjectBaggage(r, out)
+*/
+func InjectBaggage(from, out *http.Request) {
+	items, _ := from.Context().Value(baggageKey).(map[string]string)
+	if len(items) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	members := make([]string, len(keys))
+	for i, k := range keys {
+		members[i] = url.PathEscape(k) + "=" + url.PathEscape(items[k])
+	}
+	out.Header.Set("Baggage", strings.Join(members, ","))
+}
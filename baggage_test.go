@@ -0,0 +1,37 @@
+package httprouterpersist
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBaggage(t *testing.T) {
+	base := httptest.NewRequest("GET", "/", nil)
+	r1 := WithBaggage(base, "tenant", "acme")
+	r2 := WithBaggage(r1, "user id", "42")
+
+	if got := Baggage(r2, "tenant"); got != "acme" {
+		t.Errorf("Baggage(tenant) = %q, want acme", got)
+	}
+	if got := Baggage(r2, "user id"); got != "42" {
+		t.Errorf("Baggage(user id) = %q, want 42", got)
+	}
+	if got := Baggage(r1, "user id"); got != "" {
+		t.Errorf("earlier request sees Baggage(user id) = %q", got)
+	}
+	if got := Baggage(base, "tenant"); got != "" {
+		t.Errorf("request without baggage = %q", got)
+	}
+
+	out := httptest.NewRequest("GET", "http://upstream/", nil)
+	InjectBaggage(r2, out)
+	if got := out.Header.Get("Baggage"); got != "tenant=acme,user%20id=42" {
+		t.Errorf("Baggage header = %q", got)
+	}
+
+	out = httptest.NewRequest("GET", "http://upstream/", nil)
+	InjectBaggage(base, out)
+	if _, ok := out.Header["Baggage"]; ok {
+		t.Error("Baggage header set for a request without baggage")
+	}
+}
@@ -0,0 +1,263 @@
+package httprouterpersist
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+Populates the fields of the struct pointed to by dst from the request url
+query params. Fields are matched using the `query` struct tag; untagged fields
+and fields tagged "-" are skipped. Strings, bools, ints, uints and floats are
+converted from the query value and slices of those types collect every value
+of a repeated param. Fields whose param is missing are left untouched.
+
+	type Filter struct {
+		Name  string   `query:"name"`
+		Limit int      `query:"limit"`
+		Tags  []string `query:"tag"`
+	}
+
+	var f Filter
+	if err := router.BindQuery(r, &f); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+*/
+func BindQuery(r *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("httprouterpersist: BindQuery requires a non-nil pointer to a struct")
+	}
+	v = v.Elem()
+	t := v.Type()
+	values := r.URL.Query()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" || field.PkgPath != "" {
+			continue
+		}
+		raw, ok := values[name]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(fv.Type(), len(raw), len(raw))
+			for j, s := range raw {
+				if err := setValue(slice.Index(j), s); err != nil {
+					return fmt.Errorf("httprouterpersist: query param %q: %v", name, err)
+				}
+			}
+			fv.Set(slice)
+			continue
+		}
+		if err := setValue(fv, raw[0]); err != nil {
+			return fmt.Errorf("httprouterpersist: query param %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+/*
+Returns an error if more than one of the query params keys is present in the
+request url. Having none of them is allowed.
+
+	if err := router.RequireExclusive(r, "since", "cursor"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+*/
+func RequireExclusive(r *http.Request, keys ...string) error {
+	values := r.URL.Query()
+	var present []string
+	for _, k := range keys {
+		if _, ok := values[k]; ok {
+			present = append(present, k)
+		}
+	}
+	if len(present) > 1 {
+		return fmt.Errorf("httprouterpersist: query params %s are mutually exclusive", strings.Join(present, ", "))
+	}
+	return nil
+}
+
+/*
+Converts s to the kind of v and assigns it.
+*/
+func setValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to bool", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, v.Type())
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+/*
+Decodes the request body one JSON value at a time and calls fn with each of
+them, so large payloads are never buffered as a whole. The body may either
+be a top-level JSON array, in which case fn is called per element, or a
+stream of newline-delimited JSON values. Decoding stops at the first error
+returned by fn, which is returned as is.
+
+	err := router.DecodeJSONStream(r, func(raw json.RawMessage) error {
+		var item Item
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return err
+		}
+		return store.Import(item)
+	})
+*/
+func DecodeJSONStream(r *http.Request, fn func(json.RawMessage) error) error {
+	body := bufio.NewReader(r.Body)
+	array := false
+	for {
+		c, err := body.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+		array = c == '['
+		body.UnreadByte()
+		break
+	}
+
+	dec := json.NewDecoder(body)
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+The JSONError type is returned by BindJSONStrict when the request body cannot
+be decoded. Field is the dotted path of the offending field, if known, and
+Expected the Go type it should have decoded to; both are empty for syntax
+errors. Offset is the byte offset in the body at which decoding failed. Err
+is the error returned by encoding/json.
+*/
+type JSONError struct {
+	Field    string `json:"field,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Offset   int64  `json:"offset"`
+	Err      error  `json:"-"`
+}
+
+func (e *JSONError) Error() string {
+	switch {
+	case e.Expected != "":
+		return fmt.Sprintf("httprouterpersist: field %q must be of type %s (offset %d)", e.Field, e.Expected, e.Offset)
+	case e.Field != "":
+		return fmt.Sprintf("httprouterpersist: unknown field %q (offset %d)", e.Field, e.Offset)
+	}
+	return fmt.Sprintf("httprouterpersist: invalid JSON at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Decodes the JSON request body into dst, rejecting unknown fields and trailing
+data. Decoding errors are returned as a *JSONError naming the offending field
+and its position, so that they can be reported to the client:
+
+	var u User
+	if err := router.BindJSONStrict(r, &u); err != nil {
+		var jerr *router.JSONError
+		if errors.As(err, &jerr) {
+			router.WriteJSON(w, http.StatusBadRequest, jerr)
+			return
+		}
+		...
+	}
+*/
+func BindJSONStrict(r *http.Request, dst interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return jsonError(err, dec.InputOffset())
+	}
+	if dec.More() {
+		return &JSONError{Offset: dec.InputOffset(), Err: errors.New("unexpected data after JSON value")}
+	}
+	return nil
+}
+
+func jsonError(err error, offset int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &JSONError{Offset: syntaxErr.Offset, Err: err}
+	case errors.As(err, &typeErr):
+		return &JSONError{Field: typeErr.Field, Expected: typeErr.Type.String(), Offset: typeErr.Offset, Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return &JSONError{Offset: offset, Err: err}
+	}
+	// encoding/json reports unknown fields as a plain error.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if name, err := strconv.Unquote(field); err == nil {
+			field = name
+		}
+		return &JSONError{Field: field, Offset: offset, Err: err}
+	}
+	return err
+}
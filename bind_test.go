@@ -0,0 +1,155 @@
+package httprouterpersist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindQuery(t *testing.T) {
+	type filter struct {
+		Name    string   `query:"name"`
+		Limit   int      `query:"limit"`
+		Active  bool     `query:"active"`
+		Tags    []string `query:"tag"`
+		Missing int      `query:"missing"`
+		Skipped string   `query:"-"`
+	}
+
+	var f filter
+	req := httptest.NewRequest("GET", "/items?name=shoe&limit=20&active=true&tag=a&tag=b&Skipped=x", nil)
+	if err := BindQuery(req, &f); err != nil {
+		t.Fatal(err)
+	}
+	want := filter{Name: "shoe", Limit: 20, Active: true, Tags: []string{"a", "b"}}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("BindQuery = %+v, want %+v", f, want)
+	}
+}
+
+func TestBindQueryErrors(t *testing.T) {
+	var f struct {
+		Limit int `query:"limit"`
+	}
+	if err := BindQuery(httptest.NewRequest("GET", "/items?limit=ten", nil), &f); err == nil {
+		t.Error("expected an error for a non-numeric int")
+	}
+	if err := BindQuery(httptest.NewRequest("GET", "/items", nil), f); err == nil {
+		t.Error("expected an error for a non-pointer")
+	}
+}
+
+func TestDecodeJSONStream(t *testing.T) {
+	const n = 10000
+	var b strings.Builder
+	b.WriteString(" [")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id":%d}`, i)
+	}
+	b.WriteString("]")
+
+	for name, body := range map[string]string{
+		"array":  b.String(),
+		"ndjson": "{\"id\":0}\n{\"id\":1}\n",
+	} {
+		var ids []int
+		req := httptest.NewRequest("POST", "/import", strings.NewReader(body))
+		err := DecodeJSONStream(req, func(raw json.RawMessage) error {
+			var item struct{ ID int }
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return err
+			}
+			ids = append(ids, item.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := n
+		if name == "ndjson" {
+			want = 2
+		}
+		if len(ids) != want || ids[len(ids)-1] != want-1 {
+			t.Errorf("%s: decoded %d elements, want %d", name, len(ids), want)
+		}
+	}
+}
+
+func TestDecodeJSONStreamStopsOnError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	req := httptest.NewRequest("POST", "/import", strings.NewReader(`[1, 2, 3, 4]`))
+	err := DecodeJSONStream(req, func(raw json.RawMessage) error {
+		if calls++; string(raw) == "2" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("DecodeJSONStream = %v, want the error returned by fn", err)
+	}
+	if calls != 2 {
+		t.Errorf("fn called %d times, want 2", calls)
+	}
+}
+
+func TestBindJSONStrict(t *testing.T) {
+	type user struct {
+		Name    string `json:"name"`
+		Age     int    `json:"age"`
+		Address struct {
+			Zip int `json:"zip"`
+		} `json:"address"`
+	}
+	bind := func(body string) (*JSONError, error) {
+		var u user
+		err := BindJSONStrict(httptest.NewRequest("POST", "/users", strings.NewReader(body)), &u)
+		var jerr *JSONError
+		errors.As(err, &jerr)
+		return jerr, err
+	}
+
+	if _, err := bind(`{"name":"alice","age":30,"address":{"zip":12345}}`); err != nil {
+		t.Errorf("valid body: %v", err)
+	}
+
+	jerr, err := bind(`{"name":"alice","address":{"zip":"abc"}}`)
+	if jerr == nil || jerr.Field != "address.zip" || jerr.Expected != "int" || jerr.Offset == 0 {
+		t.Errorf("type mismatch = %#v (%v), want field address.zip, type int and an offset", jerr, err)
+	}
+
+	jerr, err = bind(`{"name":"alice","admin":true}`)
+	if jerr == nil || jerr.Field != "admin" || jerr.Expected != "" {
+		t.Errorf("unknown field = %#v (%v), want field admin", jerr, err)
+	}
+
+	for _, body := range []string{`{"name":`, `{"name":"alice"} {}`, `{"name":'alice'}`} {
+		if jerr, err := bind(body); jerr == nil {
+			t.Errorf("body %q = %v, want a *JSONError", body, err)
+		}
+	}
+}
+
+func TestRequireExclusive(t *testing.T) {
+	for target, wantErr := range map[string]bool{
+		"/events?since=2024-01-01":      false,
+		"/events?cursor=abc&limit=10":   false,
+		"/events":                       false,
+		"/events?since=2024&cursor=abc": true,
+	} {
+		err := RequireExclusive(httptest.NewRequest("GET", target, nil), "since", "cursor")
+		if (err != nil) != wantErr {
+			t.Errorf("%s: err = %v, want error %v", target, err, wantErr)
+		}
+		if err != nil && (!strings.Contains(err.Error(), "since") || !strings.Contains(err.Error(), "cursor")) {
+			t.Errorf("%s: error %q does not name both params", target, err)
+		}
+	}
+}
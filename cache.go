@@ -0,0 +1,48 @@
+package httprouterpersist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Returns a deterministic cache key for r built from its method, path, query
+parameters sorted by name and the values of the named request headers. Two
+requests that differ only in the order of their query parameters or in
+headers that are not named get the same key. The key is the hex encoded
+SHA-256 of those parts.
+
+	key := router.CacheKey(r, "Accept", "Accept-Encoding")
+*/
+func CacheKey(r *http.Request, includeHeaders ...string) string {
+	h := sha256.New()
+	writeKeyPart(h, r.Method)
+	writeKeyPart(h, r.URL.Path)
+	writeKeyPart(h, r.URL.Query().Encode())
+
+	names := make([]string, len(includeHeaders))
+	for i, name := range includeHeaders {
+		names[i] = http.CanonicalHeaderKey(name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeKeyPart(h, name)
+		writeKeyPart(h, strings.Join(r.Header.Values(name), ","))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+/*
+Writes s prefixed with its length, so that the boundaries between parts
+cannot be forged by their contents.
+*/
+func writeKeyPart(w io.Writer, s string) {
+	io.WriteString(w, strconv.Itoa(len(s)))
+	io.WriteString(w, ":")
+	io.WriteString(w, s)
+}
@@ -0,0 +1,37 @@
+package httprouterpersist
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCacheKey(t *testing.T) {
+	key := func(target, accept string) string {
+		req := httptest.NewRequest("GET", target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		req.Header.Set("X-Request-ID", target)
+		return CacheKey(req, "accept")
+	}
+
+	base := key("/products?color=red&size=m", "application/json")
+	if len(base) != 64 {
+		t.Errorf("key %q is not a hex SHA-256", base)
+	}
+	if got := key("/products?color=red&size=m", "application/json"); got != base {
+		t.Error("identical requests got different keys")
+	}
+	if got := key("/products?size=m&color=red", "application/json"); got != base {
+		t.Error("reordered query params changed the key")
+	}
+	if got := key("/products?color=red&size=m", "text/html"); got == base {
+		t.Error("a different included header did not change the key")
+	}
+	if got := key("/products?color=red&size=l", "application/json"); got == base {
+		t.Error("a different query value did not change the key")
+	}
+	if got := CacheKey(httptest.NewRequest("HEAD", "/products?color=red&size=m", nil)); got == CacheKey(httptest.NewRequest("GET", "/products?color=red&size=m", nil)) {
+		t.Error("a different method did not change the key")
+	}
+}
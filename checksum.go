@@ -0,0 +1,58 @@
+package httprouterpersist
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net"
+	"net/http"
+)
+
+/*
+Returns a middleware that sends the hex encoded SHA-256 of the response body
+in the trailer trailerName once the handler has finished. The checksum is
+computed as the body is written, so the body is not buffered. Trailers are
+only delivered over chunked HTTP/1.1 responses and HTTP/2; the trailer is
+declared before the handler runs, which makes net/http use chunked encoding
+instead of a Content-Length.
+
+	r.Use(router.ChecksumTrailerMiddleware("X-Content-SHA256"))
+*/
+func ChecksumTrailerMiddleware(trailerName string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("Trailer", trailerName)
+			cw := &checksumWriter{ResponseWriter: w, sum: sha256.New()}
+			next.ServeHTTP(cw, req)
+			w.Header().Set(trailerName, hex.EncodeToString(cw.sum.Sum(nil)))
+		})
+	}
+}
+
+type checksumWriter struct {
+	http.ResponseWriter
+	sum hash.Hash
+}
+
+func (w *checksumWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.sum.Write(b[:n])
+	return n, err
+}
+
+func (w *checksumWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *checksumWriter) Flush() {
+	w.flush()
+}
+
+func (w *checksumWriter) flush() bool {
+	return Flush(w.ResponseWriter)
+}
+
+func (w *checksumWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijack(w.ResponseWriter)
+}
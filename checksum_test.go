@@ -0,0 +1,47 @@
+package httprouterpersist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChecksumTrailerMiddleware(t *testing.T) {
+	body := strings.Repeat("chunk of data\n", 1000)
+	r := New()
+	r.Use(ChecksumTrailerMiddleware("X-Content-SHA256"))
+	r.GET("/export", func(w http.ResponseWriter, req *http.Request) {
+		for i := 0; i < len(body); i += 4096 {
+			end := i + 4096
+			if end > len(body) {
+				end = len(body)
+			}
+			w.Write([]byte(body[i:end]))
+			Flush(w)
+		}
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Fatalf("read %d bytes, want %d", len(got), len(body))
+	}
+
+	sum := sha256.Sum256(got)
+	if trailer := resp.Trailer.Get("X-Content-SHA256"); trailer != hex.EncodeToString(sum[:]) {
+		t.Errorf("trailer = %q, want %x", trailer, sum)
+	}
+}
@@ -0,0 +1,143 @@
+package httprouterpersist
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+/*
+The coalescedCall type is a request in flight for an idempotency key. Its
+response is recorded so that it can be replayed to the requests that waited
+for it once done is closed.
+*/
+type coalescedCall struct {
+	done   chan struct{}
+	ok     bool
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+/*
+Returns a middleware that coalesces concurrent unsafe requests (POST, PUT,
+PATCH and DELETE) that carry the same Idempotency-Key header for the same
+method and path. The first request runs the handler; the others wait for it
+and get a copy of its response instead of running the handler again:
+
+	r.Use(router.CoalesceMiddleware())
+
+Only requests that are in flight at the same time are coalesced, the
+response is not kept once it has been sent, so a retry that arrives later
+runs the handler again. Requests without the header, and safe methods, are
+passed through. If the handler panics the waiting requests get a 500.
+
+The package has no idempotency store to integrate with: the middleware keeps
+its own map of the calls in flight and nothing else. Replaying responses to
+later retries needs a store of its own, outside this middleware.
+*/
+func CoalesceMiddleware() func(http.Handler) http.Handler {
+	var (
+		mu    sync.Mutex
+		calls = make(map[string]*coalescedCall)
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			idem := req.Header.Get("Idempotency-Key")
+			if idem == "" || !isUnsafeMethod(req.Method) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			key := req.Method + " " + req.URL.Path + " " + idem
+
+			mu.Lock()
+			if c, ok := calls[key]; ok {
+				mu.Unlock()
+				select {
+				case <-c.done:
+					c.replay(w)
+				case <-req.Context().Done():
+				}
+				return
+			}
+			c := &coalescedCall{done: make(chan struct{})}
+			calls[key] = c
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				delete(calls, key)
+				mu.Unlock()
+				close(c.done)
+			}()
+			cw := &coalesceWriter{statusWriter: newStatusWriter(w), call: c}
+			next.ServeHTTP(cw, req)
+			cw.finish()
+		})
+	}
+}
+
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (c *coalescedCall) replay(w http.ResponseWriter) {
+	if !c.ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	h := w.Header()
+	for k, v := range c.header {
+		h[k] = append([]string(nil), v...)
+	}
+	w.WriteHeader(c.status)
+	w.Write(c.body.Bytes())
+}
+
+/*
+The coalesceWriter type writes the response through to the client while
+recording it on the call.
+*/
+type coalesceWriter struct {
+	*statusWriter
+	call *coalescedCall
+}
+
+func (w *coalesceWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.call.header = w.Header().Clone()
+	}
+	w.statusWriter.WriteHeader(code)
+}
+
+func (w *coalesceWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.call.body.Write(b)
+	return w.statusWriter.Write(b)
+}
+
+func (w *coalesceWriter) Flush() {
+	w.flush()
+}
+
+func (w *coalesceWriter) flush() bool {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.statusWriter.flush()
+}
+
+func (w *coalesceWriter) finish() {
+	if w.call.header == nil {
+		w.call.header = w.Header().Clone()
+	}
+	w.call.status = w.status
+	w.call.ok = true
+}
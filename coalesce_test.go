@@ -0,0 +1,83 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesceMiddleware(t *testing.T) {
+	const n = 8
+	var (
+		runs     int32
+		arrived  sync.WaitGroup
+		release  = make(chan struct{})
+		recs     [n]*httptest.ResponseRecorder
+		finished sync.WaitGroup
+	)
+	arrived.Add(n)
+
+	r := New()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			arrived.Done()
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.Use(CoalesceMiddleware())
+	r.PUT("/orders/:id", func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		w.Header().Set("Location", "/orders/"+Param(req, "id"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("order " + Param(req, "id")))
+	})
+
+	for i := range recs {
+		recs[i] = httptest.NewRecorder()
+		finished.Add(1)
+		go func(rec *httptest.ResponseRecorder) {
+			defer finished.Done()
+			req := httptest.NewRequest("PUT", "/orders/7", strings.NewReader("{}"))
+			req.Header.Set("Idempotency-Key", "abc")
+			r.ServeHTTP(rec, req)
+		}(recs[i])
+	}
+	arrived.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	finished.Wait()
+
+	if runs != 1 {
+		t.Errorf("handler ran %d times, want 1", runs)
+	}
+	for i, rec := range recs {
+		if rec.Code != http.StatusCreated || rec.Body.String() != "order 7" || rec.Header().Get("Location") != "/orders/7" {
+			t.Errorf("caller %d got %d %q Location=%q", i, rec.Code, rec.Body.String(), rec.Header().Get("Location"))
+		}
+	}
+}
+
+func TestCoalesceMiddlewarePassThrough(t *testing.T) {
+	var runs int32
+	r := New()
+	r.Use(CoalesceMiddleware())
+	r.PUT("/orders/:id", func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&runs, 1)
+	})
+
+	for _, key := range []string{"", "abc", "abc"} {
+		req := httptest.NewRequest("PUT", "/orders/7", nil)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if runs != 3 {
+		t.Errorf("handler ran %d times, want 3 for sequential requests", runs)
+	}
+}
@@ -0,0 +1,139 @@
+package httprouterpersist
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+)
+
+/*
+The metadata key that disables response compression for a route. Use it for
+routes that serve content that is already compressed:
+
+	r.HandleMeta("GET", "/backup.tar.gz", map[string]interface{}{
+		router.MetaNoCompress: true,
+	}, ServeBackup)
+*/
+const MetaNoCompress = "noCompress"
+
+/*
+Returns a middleware that gzip compresses responses for clients that accept
+gzip. Responses are left alone when the matched route has MetaNoCompress set,
+when the handler already set a Content-Encoding, and for HEAD requests and
+bodiless statuses. The decision is made when the handler first writes, so
+the middleware can be installed globally with Use and still honour the
+metadata of the route that is matched later.
+*/
+func GzipMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !acceptsGzip(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			w.Header().Add("Vary", "Accept-Encoding")
+			withState(req, func(req *http.Request, s *requestState) {
+				gw := &gzipWriter{ResponseWriter: w, req: req, state: s}
+				defer gw.close()
+				next.ServeHTTP(gw, req)
+			})
+		})
+	}
+}
+
+func acceptsGzip(req *http.Request) bool {
+	for _, part := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+type gzipWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	state       *requestState
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.compress(code) {
+			h := w.Header()
+			h.Set("Content-Encoding", "gzip")
+			h.Del("Content-Length")
+			w.gz = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+/*
+Flushes the compressed data written so far to the client.
+*/
+func (w *gzipWriter) Flush() {
+	w.flush()
+}
+
+func (w *gzipWriter) flush() bool {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	return Flush(w.ResponseWriter)
+}
+
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+/*
+Hijacks the connection. The response is left uncompressed, since the
+handler takes over the connection.
+*/
+func (w *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return hijack(w.ResponseWriter)
+}
+
+func (w *gzipWriter) compress(code int) bool {
+	if w.req.Method == http.MethodHead || code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+		return false
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	if w.state.route != nil {
+		if noCompress, _ := w.state.route.Meta[MetaNoCompress].(bool); noCompress {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *gzipWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
@@ -0,0 +1,51 @@
+package httprouterpersist
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveGzip(r http.Handler, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	body := strings.Repeat("compress me ", 100)
+	r := New()
+	r.Use(GzipMiddleware())
+	r.GET("/text", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(body))
+	})
+	r.HandleMeta("GET", "/archive.gz", map[string]interface{}{MetaNoCompress: true}, func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(body))
+	})
+
+	rec := serveGzip(r, "/text")
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("GET /text Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != body {
+		t.Errorf("GET /text decompressed body = %q", b)
+	}
+
+	rec = serveGzip(r, "/archive.gz")
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != body {
+		t.Errorf("GET /archive.gz was compressed: Content-Encoding = %q", rec.Header().Get("Content-Encoding"))
+	}
+
+	if rec := serve(r, "GET", "/text"); rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != body {
+		t.Errorf("GET /text without Accept-Encoding was compressed")
+	}
+}
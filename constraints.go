@@ -0,0 +1,94 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+/*
+The ConstraintFailureMode type selects how the router answers requests whose
+params fail the constraints of a GETValidated or GETRegex route.
+*/
+type ConstraintFailureMode int
+
+const (
+	// ConstraintNotFound answers with the NotFound handler, as if the route
+	// did not match.
+	ConstraintNotFound ConstraintFailureMode = iota
+	// ConstraintBadRequest answers with a 400 and a JSON body naming the
+	// param and the constraint it failed.
+	ConstraintBadRequest
+)
+
+/*
+The paramConstraint type checks the value of a single route param.
+*/
+type paramConstraint struct {
+	param      string
+	constraint string
+	valid      func(string) bool
+}
+
+/*
+Registers a GET route whose params must pass the given validators, keyed by
+param name. Requests with a param that fails its validator are rejected
+according to the router's ConstraintFailureMode.
+
+	r.GETValidated("/users/:id", map[string]func(string) bool{
+		"id": func(v string) bool { _, err := strconv.Atoi(v); return err == nil },
+	}, ShowUser)
+*/
+func (r *Router) GETValidated(path string, validators map[string]func(string) bool, fn http.HandlerFunc) *Route {
+	constraints := make([]paramConstraint, 0, len(validators))
+	for param, valid := range validators {
+		constraints = append(constraints, paramConstraint{param, "validator", valid})
+	}
+	return r.GET(path, r.constrained(constraints, fn))
+}
+
+/*
+Registers a GET route whose params must match the given regular expressions,
+keyed by param name. The expressions must match the whole value and are
+compiled at registration, which panics if one is invalid.
+
+	r.GETRegex("/posts/:slug", map[string]string{"slug": `[a-z0-9-]+`}, ShowPost)
+*/
+func (r *Router) GETRegex(path string, patterns map[string]string, fn http.HandlerFunc) *Route {
+	constraints := make([]paramConstraint, 0, len(patterns))
+	for param, pattern := range patterns {
+		re := regexp.MustCompile(`^(?:` + pattern + `)$`)
+		constraints = append(constraints, paramConstraint{param, pattern, re.MatchString})
+	}
+	return r.GET(path, r.constrained(constraints, fn))
+}
+
+/*
+Wraps fn so that it only runs when every param passes its constraint.
+*/
+func (r *Router) constrained(constraints []paramConstraint, fn http.HandlerFunc) http.HandlerFunc {
+	sort.Slice(constraints, func(i, j int) bool {
+		return constraints[i].param < constraints[j].param
+	})
+	return func(w http.ResponseWriter, req *http.Request) {
+		for _, c := range constraints {
+			if !c.valid(Param(req, c.param)) {
+				r.rejectParam(w, req, c)
+				return
+			}
+		}
+		fn(w, req)
+	}
+}
+
+func (r *Router) rejectParam(w http.ResponseWriter, req *http.Request, c paramConstraint) {
+	if r.ConstraintFailureMode != ConstraintBadRequest {
+		r.notFound(w, req)
+		return
+	}
+	WriteJSON(w, http.StatusBadRequest, map[string]string{
+		"error":      "invalid param",
+		"param":      c.param,
+		"constraint": c.constraint,
+	})
+}
@@ -0,0 +1,50 @@
+package httprouterpersist
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestConstraintFailureModes(t *testing.T) {
+	newRouter := func(mode ConstraintFailureMode) *Router {
+		r := New()
+		r.ConstraintFailureMode = mode
+		r.GETValidated("/users/:id", map[string]func(string) bool{
+			"id": func(v string) bool { _, err := strconv.Atoi(v); return err == nil },
+		}, func(w http.ResponseWriter, req *http.Request) {})
+		r.GETRegex("/posts/:slug", map[string]string{"slug": `[a-z0-9-]+`}, func(w http.ResponseWriter, req *http.Request) {})
+		return r
+	}
+
+	r := newRouter(ConstraintNotFound)
+	for path, want := range map[string]int{"/users/42": 200, "/users/x": 404, "/posts/hello-1": 200, "/posts/Hello": 404} {
+		if rec := serve(r, "GET", path); rec.Code != want {
+			t.Errorf("not found mode: GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+	if rec := serve(r, "GET", "/users/x"); rec.Body.String() != "404 page not found\n" {
+		t.Errorf("not found mode: body = %q, want the plain NotFound body", rec.Body.String())
+	}
+
+	r = newRouter(ConstraintBadRequest)
+	for path, want := range map[string]map[string]string{
+		"/users/x":     {"error": "invalid param", "param": "id", "constraint": "validator"},
+		"/posts/Hello": {"error": "invalid param", "param": "slug", "constraint": "[a-z0-9-]+"},
+	} {
+		rec := serve(r, "GET", path)
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusBadRequest {
+			t.Fatalf("bad request mode: GET %s = %d %q", path, rec.Code, rec.Body.String())
+		}
+		for k, v := range want {
+			if body[k] != v {
+				t.Errorf("bad request mode: GET %s %s = %q, want %q", path, k, body[k], v)
+			}
+		}
+	}
+	if rec := serve(r, "GET", "/users/42"); rec.Code != http.StatusOK {
+		t.Errorf("bad request mode: GET /users/42 = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package httprouterpersist
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+/*
+The Credentials type holds the parsed Authorization header of a request.
+Scheme is the auth scheme as sent, e.g. "Bearer" or "Basic". Token holds the
+credentials following the scheme; for the Basic scheme User and Pass hold the
+decoded user name and password.
+*/
+type Credentials struct {
+	Scheme string
+	Token  string
+	User   string
+	Pass   string
+}
+
+/*
+Returns a middleware that parses the Authorization header of every request
+so that auth middleware and handlers can read it with GetCredentials instead
+of parsing it again. The middleware does not authenticate anything and never
+rejects a request.
+*/
+func CredentialsMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			withState(req, func(req *http.Request, s *requestState) {
+				s.credentials = nil
+				if creds, ok := parseAuthorization(req.Header.Get("Authorization")); ok {
+					s.credentials = &creds
+				}
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Returns the credentials parsed by CredentialsMiddleware and whether the
+request carried a parseable Authorization header.
+
+	creds, ok := router.GetCredentials(r)
+	if !ok || !strings.EqualFold(creds.Scheme, "Bearer") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+*/
+func GetCredentials(r *http.Request) (Credentials, bool) {
+	if s := stateFrom(r); s != nil && s.credentials != nil {
+		return *s.credentials, true
+	}
+	return Credentials{}, false
+}
+
+func parseAuthorization(header string) (Credentials, bool) {
+	scheme, token, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if scheme == "" {
+		return Credentials{}, false
+	}
+	creds := Credentials{Scheme: scheme, Token: strings.TrimSpace(token)}
+	if strings.EqualFold(scheme, "Basic") {
+		decoded, err := base64.StdEncoding.DecodeString(creds.Token)
+		if err != nil {
+			return Credentials{}, false
+		}
+		user, pass, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return Credentials{}, false
+		}
+		creds.User, creds.Pass = user, pass
+	}
+	return creds, true
+}
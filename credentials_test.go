@@ -0,0 +1,40 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCredentialsMiddleware(t *testing.T) {
+	var (
+		creds Credentials
+		ok    bool
+	)
+	r := New()
+	r.Use(CredentialsMiddleware())
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) {
+		creds, ok = GetCredentials(req)
+	})
+
+	for header, want := range map[string]struct {
+		creds Credentials
+		ok    bool
+	}{
+		"Basic YWxpY2U6czNjcjN0": {Credentials{Scheme: "Basic", Token: "YWxpY2U6czNjcjN0", User: "alice", Pass: "s3cr3t"}, true},
+		"Bearer abc.def.ghi":     {Credentials{Scheme: "Bearer", Token: "abc.def.ghi"}, true},
+		"":                       {Credentials{}, false},
+		"Basic !!!":              {Credentials{}, false},
+		"Basic YWxpY2U=":         {Credentials{}, false},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		creds, ok = Credentials{}, true
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if creds != want.creds || ok != want.ok {
+			t.Errorf("Authorization %q = %+v, %v; want %+v, %v", header, creds, ok, want.creds, want.ok)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+/*
+Marks the registered route method and path as deprecated. Every response of
+the route carries a "Deprecation: true" header, a Sunset header with
+sunsetDate and, when link is not empty, a Link header pointing to the
+deprecation documentation. sunsetDate may be given as an HTTP date, an
+RFC 3339 timestamp or a plain "2006-01-02" date; empty omits the header.
+Deprecating a route that has not been registered panics. Routes may be
+deprecated while the router is serving.
+
+	r.GET("/v1/users", ListUsersV1)
+	r.Deprecate("GET", "/v1/users", "2025-06-30", "https://docs.example.com/v2-migration")
+*/
+func (r *Router) Deprecate(method, path, sunsetDate, link string) {
+	header := http.Header{}
+	header.Set("Deprecation", "true")
+	if sunsetDate != "" {
+		header.Set("Sunset", httpDate(sunsetDate))
+	}
+	if link != "" {
+		header.Set("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", link))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.routes {
+		if rt.Method == method && rt.Path == path {
+			rt.deprecation.Store(header)
+			return
+		}
+	}
+	panic(fmt.Sprintf("httprouterpersist: cannot deprecate unregistered route %s %s", method, path))
+}
+
+/*
+Returns date formatted as an HTTP date if it can be parsed, and as given
+otherwise.
+*/
+func httpDate(date string) string {
+	for _, layout := range []string{http.TimeFormat, time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.UTC().Format(http.TimeFormat)
+		}
+	}
+	return date
+}
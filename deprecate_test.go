@@ -0,0 +1,78 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDeprecate(t *testing.T) {
+	r := New()
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.GET("/v1/users", ok)
+	r.GET("/v2/users", ok)
+	r.Deprecate("GET", "/v1/users", "2025-06-30", "https://docs.example.com/v2-migration")
+
+	rec := serve(r, "GET", "/v1/users")
+	for k, want := range map[string]string{
+		"Deprecation": "true",
+		"Sunset":      "Mon, 30 Jun 2025 00:00:00 GMT",
+		"Link":        `<https://docs.example.com/v2-migration>; rel="deprecation"`,
+	} {
+		if got := rec.Header().Get(k); got != want {
+			t.Errorf("GET /v1/users %s = %q, want %q", k, got, want)
+		}
+	}
+
+	rec = serve(r, "GET", "/v2/users")
+	for _, k := range []string{"Deprecation", "Sunset", "Link"} {
+		if got := rec.Header().Get(k); got != "" {
+			t.Errorf("GET /v2/users %s = %q, want none", k, got)
+		}
+	}
+}
+
+func TestDeprecateUnregistered(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	New().Deprecate("GET", "/v1/users", "", "")
+}
+
+func TestDeprecateWhileServing(t *testing.T) {
+	r := New()
+	r.GET("/v1/users", func(w http.ResponseWriter, req *http.Request) {})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				serve(r, "GET", "/v1/users")
+			}
+		}()
+	}
+	r.Deprecate("GET", "/v1/users", "", "")
+	wg.Wait()
+	if got := serve(r, "GET", "/v1/users").Header().Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation = %q, want true", got)
+	}
+}
+
+func TestDeprecatePaginated(t *testing.T) {
+	r := New()
+	r.GET("/v1/users", func(w http.ResponseWriter, req *http.Request) {
+		WritePaginated(w, req, []int{1}, 1, 10, 30)
+	})
+	r.Deprecate("GET", "/v1/users", "", "https://docs.example.com/v2-migration")
+
+	links := serve(r, "GET", "/v1/users").Header().Values("Link")
+	all := strings.Join(links, ", ")
+	if !strings.Contains(all, `rel="deprecation"`) || !strings.Contains(all, `rel="next"`) {
+		t.Errorf("Link = %q, want both the deprecation and the pagination links", links)
+	}
+}
@@ -0,0 +1,30 @@
+package httprouterpersist
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+)
+
+/*
+Serves the size bytes of ra as a download named name. Range requests are
+supported, including If-Range, so interrupted downloads can be resumed;
+unsatisfiable ranges get a 416. modTime is used for Last-Modified and the
+conditional request headers and may be the zero time. Unlike http.ServeFile,
+the content can come from any io.ReaderAt, such as a blob store client.
+
+	r.GET("/exports/:id", func(w http.ResponseWriter, r *http.Request) {
+		blob := store.Open(router.Param(r, "id"))
+		router.ServeReaderAt(w, r, blob, blob.Size(), blob.ModTime(), blob.Name())
+	})
+*/
+func ServeReaderAt(w http.ResponseWriter, r *http.Request, ra io.ReaderAt, size int64, modTime time.Time, name string) {
+	h := w.Header()
+	h.Set("Accept-Ranges", "bytes")
+	if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(name)}); disposition != "" {
+		h.Set("Content-Disposition", disposition)
+	}
+	http.ServeContent(w, r, name, modTime, io.NewSectionReader(ra, 0, size))
+}
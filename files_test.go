@@ -0,0 +1,49 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeReaderAt(t *testing.T) {
+	content := "0123456789abcdefghij"
+	r := New()
+	r.GET("/exports/:id", func(w http.ResponseWriter, req *http.Request) {
+		ServeReaderAt(w, req, strings.NewReader(content), int64(len(content)), time.Time{}, "exports/"+Param(req, "id")+".txt")
+	})
+	get := func(rangeHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/exports/42", nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	if rec.Code != http.StatusOK || rec.Body.String() != content {
+		t.Errorf("full download = %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=42.txt" {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if got := rec.Header().Get("Accept-Ranges"); got != "bytes" {
+		t.Errorf("Accept-Ranges = %q, want bytes", got)
+	}
+
+	rec = get("bytes=10-14")
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "abcde" {
+		t.Errorf("range download = %d %q, want 206 \"abcde\"", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes 10-14/20" {
+		t.Errorf("Content-Range = %q, want bytes 10-14/20", got)
+	}
+
+	if rec := get("bytes=30-40"); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("invalid range = %d, want 416", rec.Code)
+	}
+}
@@ -0,0 +1,35 @@
+package httprouterpersist
+
+import (
+	"net/http"
+)
+
+/*
+Returns a middleware that evaluates the feature flags of every request once,
+using eval, so that handlers can check them cheaply with FeatureEnabled.
+
+	r.Use(router.FeatureFlagMiddleware(func(r *http.Request) map[string]bool {
+		return flags.For(r.Header.Get("X-User-ID"))
+	}))
+*/
+func FeatureFlagMiddleware(eval func(*http.Request) map[string]bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			withState(req, func(req *http.Request, s *requestState) {
+				s.flags = eval(req)
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Reports whether the feature flag name was enabled for the request by
+FeatureFlagMiddleware. Unknown flags are disabled.
+*/
+func FeatureEnabled(r *http.Request, name string) bool {
+	if s := stateFrom(r); s != nil {
+		return s.flags[name]
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFeatureFlagMiddleware(t *testing.T) {
+	evals := 0
+	r := New()
+	r.Use(FeatureFlagMiddleware(func(req *http.Request) map[string]bool {
+		evals++
+		return map[string]bool{"beta": req.Header.Get("X-User-ID") == "42", "dark-mode": true}
+	}))
+	var beta, darkMode, unknown bool
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) {
+		beta = FeatureEnabled(req, "beta")
+		darkMode = FeatureEnabled(req, "dark-mode")
+		unknown = FeatureEnabled(req, "unknown")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-User-ID", "42")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if !beta || !darkMode || unknown {
+		t.Errorf("flags = beta %v, dark-mode %v, unknown %v; want true, true, false", beta, darkMode, unknown)
+	}
+	if evals != 1 {
+		t.Errorf("eval called %d times, want 1", evals)
+	}
+
+	serve(r, "GET", "/")
+	if beta {
+		t.Error("beta enabled for another user")
+	}
+	if FeatureEnabled(httptest.NewRequest("GET", "/", nil), "beta") {
+		t.Error("flag enabled without the middleware")
+	}
+}
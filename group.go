@@ -0,0 +1,135 @@
+package httprouterpersist
+
+import (
+	"net/http"
+)
+
+/*
+The Group type registers routes on a Router under a common path prefix and
+wraps them with the group's middleware. Group middleware run after the global
+middleware and after the params have been persisted, so they can read the
+params the same way handlers do.
+
+	api := r.Group("/api")
+	api = api.WithNamed("auth", AuthMiddleware)
+	api.GET("/users/:id", ShowUser)
+*/
+type Group struct {
+	router     *Router
+	prefix     string
+	middleware []namedMiddleware
+	disabled   bool
+}
+
+/*
+Returns a new group that registers routes under prefix.
+*/
+func (r *Router) Group(prefix string) *Group {
+	return &Group{router: r, prefix: prefix}
+}
+
+/*
+Returns a new group under prefix that only registers its routes when cond is
+true. Routes registered on a disabled group, or on groups derived from it,
+are silently dropped, which keeps environment checks in one place:
+
+	debug := r.GroupIf(os.Getenv("ENV") != "production", "/debug")
+	debug.GET("/routes", r.HTMLRoutesHandler())
+*/
+func (r *Router) GroupIf(cond bool, prefix string) *Group {
+	return &Group{router: r, prefix: prefix, disabled: !cond}
+}
+
+/*
+Registers a GET route only when cond is true. When cond is false the
+returned route is not registered; annotating it has no effect.
+*/
+func (r *Router) GETIf(cond bool, path string, fn http.HandlerFunc) *Route {
+	return r.GroupIf(cond, "").GET(path, fn)
+}
+
+/*
+Returns a group without a prefix that wraps its routes with mw. This is the
+way to attach middleware to a single route:
+
+	r.With(RateLimit).POST("/login", Login)
+*/
+func (r *Router) With(mw ...func(http.Handler) http.Handler) *Group {
+	return r.Group("").With(mw...)
+}
+
+/*
+Like With, but the middleware is reported under the given name.
+*/
+func (r *Router) WithNamed(name string, mw func(http.Handler) http.Handler) *Group {
+	return r.Group("").WithNamed(name, mw)
+}
+
+/*
+Returns a nested group under the group's prefix that inherits the group's
+middleware.
+*/
+func (g *Group) Group(prefix string) *Group {
+	return &Group{router: g.router, prefix: g.prefix + prefix, middleware: g.middleware, disabled: g.disabled}
+}
+
+/*
+Returns a copy of the group that additionally wraps its routes with mw. Each
+middleware is named after its function.
+*/
+func (g *Group) With(mw ...func(http.Handler) http.Handler) *Group {
+	ng := g
+	for _, fn := range mw {
+		ng = ng.WithNamed(middlewareName(fn), fn)
+	}
+	return ng
+}
+
+/*
+Like With, but the middleware is reported under the given name.
+*/
+func (g *Group) WithNamed(name string, mw func(http.Handler) http.Handler) *Group {
+	middleware := make([]namedMiddleware, len(g.middleware), len(g.middleware)+1)
+	copy(middleware, g.middleware)
+	return &Group{
+		router:     g.router,
+		prefix:     g.prefix,
+		middleware: append(middleware, namedMiddleware{name, mw}),
+		disabled:   g.disabled,
+	}
+}
+
+func (g *Group) Handle(method, path string, fn http.HandlerFunc) *Route {
+	if g.disabled {
+		return &Route{Method: method, Path: g.prefix + path}
+	}
+	return g.router.handle(method, g.prefix+path, nil, g.middleware, fn)
+}
+
+func (g *Group) DELETE(path string, fn http.HandlerFunc) *Route {
+	return g.Handle("DELETE", path, fn)
+}
+
+func (g *Group) GET(path string, fn http.HandlerFunc) *Route {
+	return g.Handle("GET", path, fn)
+}
+
+func (g *Group) HEAD(path string, fn http.HandlerFunc) *Route {
+	return g.Handle("HEAD", path, fn)
+}
+
+func (g *Group) OPTIONS(path string, fn http.HandlerFunc) *Route {
+	return g.Handle("OPTIONS", path, fn)
+}
+
+func (g *Group) PATCH(path string, fn http.HandlerFunc) *Route {
+	return g.Handle("PATCH", path, fn)
+}
+
+func (g *Group) POST(path string, fn http.HandlerFunc) *Route {
+	return g.Handle("POST", path, fn)
+}
+
+func (g *Group) PUT(path string, fn http.HandlerFunc) *Route {
+	return g.Handle("PUT", path, fn)
+}
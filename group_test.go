@@ -0,0 +1,32 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGETIfAndGroupIf(t *testing.T) {
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r := New()
+	r.GETIf(true, "/on", ok)
+	r.GETIf(false, "/off", ok)
+	r.GroupIf(true, "/debug").GET("/routes", ok)
+	off := r.GroupIf(false, "/internal")
+	off.GET("/routes", ok)
+	off.Group("/nested").GET("/x", ok)
+
+	for path, want := range map[string]int{
+		"/on":                http.StatusOK,
+		"/off":               http.StatusNotFound,
+		"/debug/routes":      http.StatusOK,
+		"/internal/routes":   http.StatusNotFound,
+		"/internal/nested/x": http.StatusNotFound,
+	} {
+		if rec := serve(r, "GET", path); rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+	if n := len(r.routeList()); n != 2 {
+		t.Errorf("%d routes tracked, want 2", n)
+	}
+}
@@ -0,0 +1,60 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+)
+
+/*
+The metadata key that declares static response headers for a route, applied
+when RouteHeadersMiddleware is installed. The value is a map[string]string
+or an http.Header. It is read when the route is registered, which panics if
+it is of another type.
+
+	r.GETMeta("/feed.xml", map[string]interface{}{
+		router.MetaHeaders: map[string]string{"Cache-Control": "public, max-age=300"},
+	}, ServeFeed)
+*/
+const MetaHeaders = "headers"
+
+/*
+Returns a middleware that makes the router set the MetaHeaders of the
+matched route on the response before the route's handler runs, so the
+handler can still override them. The headers are applied once the route is
+matched, so install it with Use:
+
+	r.Use(router.RouteHeadersMiddleware())
+*/
+func RouteHeadersMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			withState(req, func(req *http.Request, s *requestState) {
+				s.routeHeaders = true
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Returns the MetaHeaders of a route registered for path with meta.
+*/
+func routeHeaders(path string, meta map[string]interface{}) http.Header {
+	switch headers := meta[MetaHeaders].(type) {
+	case nil:
+		return nil
+	case map[string]string:
+		h := make(http.Header, len(headers))
+		for k, v := range headers {
+			h.Set(k, v)
+		}
+		return h
+	case http.Header:
+		h := make(http.Header, len(headers))
+		for k, v := range headers {
+			h[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+		}
+		return h
+	}
+	panic(fmt.Sprintf("httprouterpersist: invalid headers %v for path '%s'", meta[MetaHeaders], path))
+}
@@ -0,0 +1,50 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRouteHeadersMiddleware(t *testing.T) {
+	r := New()
+	r.Use(RouteHeadersMiddleware())
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.GETMeta("/feed", map[string]interface{}{
+		MetaHeaders: map[string]string{"cache-control": "public, max-age=300"},
+	}, ok)
+	r.GETMeta("/override", map[string]interface{}{
+		MetaHeaders: http.Header{"X-Frame-Options": {"DENY"}},
+	}, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+	})
+	r.GET("/plain", ok)
+
+	if got := serve(r, "GET", "/feed").Header().Get("Cache-Control"); got != "public, max-age=300" {
+		t.Errorf("GET /feed Cache-Control = %q", got)
+	}
+	if got := serve(r, "GET", "/override").Header().Get("X-Frame-Options"); got != "SAMEORIGIN" {
+		t.Errorf("GET /override X-Frame-Options = %q, want SAMEORIGIN", got)
+	}
+	if got := serve(r, "GET", "/plain").Header().Get("Cache-Control"); got != "" {
+		t.Errorf("GET /plain Cache-Control = %q, want none", got)
+	}
+}
+
+func TestRouteHeadersNotInstalled(t *testing.T) {
+	r := New()
+	r.GETMeta("/feed", map[string]interface{}{
+		MetaHeaders: map[string]string{"Cache-Control": "no-store"},
+	}, func(w http.ResponseWriter, req *http.Request) {})
+	if got := serve(r, "GET", "/feed").Header().Get("Cache-Control"); got != "" {
+		t.Errorf("Cache-Control = %q, want none", got)
+	}
+}
+
+func TestInvalidRouteHeaders(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	New().GETMeta("/x", map[string]interface{}{MetaHeaders: "Cache-Control: no-store"}, func(w http.ResponseWriter, req *http.Request) {})
+}
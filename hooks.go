@@ -0,0 +1,55 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"time"
+)
+
+/*
+The ResponseInfo type describes a response once its handler has returned.
+Panicked is set when the handler panicked, whether or not the panic was
+recovered by the router's PanicHandler.
+*/
+type ResponseInfo struct {
+	Status   int
+	Bytes    int
+	Duration time.Duration
+	Panicked bool
+}
+
+/*
+Registers fn to be called after every response served by the router has been
+written, including flushed responses and responses whose handler panicked.
+Hooks run in the order they were registered.
+
+	r.AfterResponse(func(req *http.Request, info router.ResponseInfo) {
+		audit.Record(req.URL.Path, info.Status, info.Bytes)
+	})
+*/
+func (r *Router) AfterResponse(fn func(*http.Request, ResponseInfo)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.after = append(r.after, fn)
+}
+
+/*
+Registers h to render responses with the given status code. When a handler,
+including the NotFound, MethodNotAllowed and PanicHandler handlers, writes
+the status code, the response is handed to h before any body is written and
+whatever the original handler writes afterwards is discarded. This is meant
+for centralized rendering of error statuses such as 401, 403, 404 and 500:
+
+	r.OnStatus(http.StatusForbidden, func(w http.ResponseWriter, r *http.Request) {
+		router.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+	})
+
+A status written by h defaults to code.
+*/
+func (r *Router) OnStatus(code int, h http.HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.onStatus == nil {
+		r.onStatus = make(map[int]http.HandlerFunc)
+	}
+	r.onStatus[code] = h
+}
@@ -0,0 +1,83 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAfterResponse(t *testing.T) {
+	r := New()
+	var calls []string
+	var info ResponseInfo
+	r.AfterResponse(func(req *http.Request, i ResponseInfo) {
+		calls = append(calls, "first")
+		info = i
+	})
+	r.AfterResponse(func(req *http.Request, i ResponseInfo) {
+		calls = append(calls, "second")
+	})
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Fatalf("hooks ran as %v", calls)
+	}
+	if info.Status != http.StatusCreated || info.Bytes != 5 || info.Panicked {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestAfterResponsePanic(t *testing.T) {
+	r := New()
+	var info ResponseInfo
+	r.AfterResponse(func(req *http.Request, i ResponseInfo) { info = i })
+	r.PanicHandler = func(w http.ResponseWriter, req *http.Request, v interface{}) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) { panic("boom") })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !info.Panicked || info.Status != http.StatusInternalServerError {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestAfterResponseKeepsFlushAndHijack(t *testing.T) {
+	r := New()
+	r.AfterResponse(func(*http.Request, ResponseInfo) {})
+	assertFlushAndHijack(t, r)
+}
+
+func TestOnStatus(t *testing.T) {
+	r := New()
+	r.OnStatus(http.StatusForbidden, func(w http.ResponseWriter, req *http.Request) {
+		WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+	})
+	r.OnStatus(http.StatusNotFound, func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("custom not found"))
+	})
+	r.GET("/admin", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("original body"))
+	})
+	r.GET("/ok", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	rec := serve(r, "GET", "/admin")
+	if rec.Code != http.StatusForbidden || strings.TrimSpace(rec.Body.String()) != `{"error":"forbidden"}` {
+		t.Errorf("GET /admin = %d %q, want the custom 403 body", rec.Code, rec.Body.String())
+	}
+	rec = serve(r, "GET", "/missing")
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "custom not found" {
+		t.Errorf("GET /missing = %d %q, want the custom 404 body", rec.Code, rec.Body.String())
+	}
+	if rec := serve(r, "GET", "/ok"); rec.Body.String() != "ok" {
+		t.Errorf("GET /ok body = %q, want ok", rec.Body.String())
+	}
+}
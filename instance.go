@@ -0,0 +1,25 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"os"
+)
+
+/*
+Returns a middleware that sets the X-Served-By response header to name, so
+that responses from a load-balanced cluster tell which instance served them.
+When name is empty the hostname of the machine is used.
+
+	r.Use(router.InstanceHeaderMiddleware(os.Getenv("POD_NAME")))
+*/
+func InstanceHeaderMiddleware(name string) func(http.Handler) http.Handler {
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Served-By", name)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
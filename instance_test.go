@@ -0,0 +1,25 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestInstanceHeaderMiddleware(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	for name, want := range map[string]string{"web-7f9c": "web-7f9c", "": hostname} {
+		r := New()
+		r.Use(InstanceHeaderMiddleware(name))
+		r.GET("/", func(w http.ResponseWriter, req *http.Request) {})
+
+		for _, path := range []string{"/", "/missing"} {
+			if got := serve(r, "GET", path).Header().Get("X-Served-By"); got != want {
+				t.Errorf("name %q: GET %s X-Served-By = %q, want %q", name, path, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Registers a GET route whose handler is resolved by calling provider the first
+time the route is requested. The resolved handler is memoized and serves all
+later requests; concurrent first requests wait for the single provider call.
+
+	r.LazyGET("/plugins/report", func() http.HandlerFunc {
+		return plugins.Load("report").Handler
+	})
+
+If provider panics or returns nil, the request gets a 500 Internal Server
+Error and nothing is memoized, so the next request calls provider again. The
+failure is logged to the request logger, see Logger, along with the panic
+value and stack.
+*/
+func (r *Router) LazyGET(path string, provider func() http.HandlerFunc) *Route {
+	var (
+		mu       sync.Mutex
+		resolved atomic.Value
+	)
+	resolve := func() (fn http.HandlerFunc, stack []byte, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fn, ok := resolved.Load().(http.HandlerFunc); ok {
+			return fn, nil, nil
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				fn, stack = nil, debug.Stack()
+				err = fmt.Errorf("httprouterpersist: handler provider for '%s' panicked: %v", path, p)
+			}
+		}()
+		if fn = provider(); fn == nil {
+			return nil, nil, fmt.Errorf("httprouterpersist: handler provider for '%s' returned nil", path)
+		}
+		resolved.Store(fn)
+		return fn, nil, nil
+	}
+
+	return r.GET(path, func(w http.ResponseWriter, req *http.Request) {
+		fn, ok := resolved.Load().(http.HandlerFunc)
+		if !ok {
+			var stack []byte
+			var err error
+			if fn, stack, err = resolve(); err != nil {
+				attrs := []interface{}{"error", err}
+				if stack != nil {
+					attrs = append(attrs, "stack", string(stack))
+				}
+				Logger(req).Error("lazy handler unavailable", attrs...)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+		}
+		fn(w, req)
+	})
+}
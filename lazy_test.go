@@ -0,0 +1,78 @@
+package httprouterpersist
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazyGET(t *testing.T) {
+	var calls int32
+	r := New()
+	r.LazyGET("/plugins/:name", func() http.HandlerFunc {
+		atomic.AddInt32(&calls, 1)
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("plugin " + Param(req, "name")))
+		}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := serve(r, "GET", "/plugins/report"); rec.Body.String() != "plugin report" {
+				t.Errorf("body = %q, want \"plugin report\"", rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if rec := serve(r, "GET", "/plugins/export"); rec.Body.String() != "plugin export" {
+		t.Errorf("body = %q, want \"plugin export\"", rec.Body.String())
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+}
+
+func TestLazyGETProviderFailure(t *testing.T) {
+	for name, tt := range map[string]struct {
+		fail func() http.HandlerFunc
+		logs []string
+	}{
+		"panic": {func() http.HandlerFunc { panic("plugin not found") }, []string{"panicked: plugin not found", "stack="}},
+		"nil":   {func() http.HandlerFunc { return nil }, []string{"returned nil"}},
+	} {
+		fail := tt.fail
+		calls := 0
+		var logs bytes.Buffer
+		r := New()
+		r.Use(LoggerMiddleware(slog.New(slog.NewTextHandler(&logs, nil))))
+		r.LazyGET("/plugin", func() http.HandlerFunc {
+			if calls++; calls == 1 {
+				return fail()
+			}
+			return func(w http.ResponseWriter, req *http.Request) {}
+		})
+
+		if rec := serve(r, "GET", "/plugin"); rec.Code != http.StatusInternalServerError {
+			t.Errorf("%s: first GET = %d, want 500", name, rec.Code)
+		}
+		for _, want := range tt.logs {
+			if !strings.Contains(logs.String(), want) {
+				t.Errorf("%s: log does not contain %q:\n%s", name, want, logs.String())
+			}
+		}
+		if rec := serve(r, "GET", "/plugin"); rec.Code != http.StatusOK {
+			t.Errorf("%s: second GET = %d, want 200", name, rec.Code)
+		}
+		if calls != 2 {
+			t.Errorf("%s: provider called %d times, want 2", name, calls)
+		}
+	}
+}
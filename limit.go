@@ -0,0 +1,126 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+Returns a middleware that limits the number of concurrent requests per client
+key, as returned by keyFunc. A request that would exceed limit for its key is
+rejected with 429 Too Many Requests; other keys are unaffected. Keys are
+evicted as soon as they have no requests in flight, so the map only holds
+active clients. It panics if limit is not positive.
+
+	limit := router.PerKeyConcurrencyMiddleware(4, func(r *http.Request) string {
+		return r.Header.Get("X-API-Key")
+	})
+*/
+func PerKeyConcurrencyMiddleware(limit int, keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
+	if limit <= 0 {
+		panic(fmt.Sprintf("httprouterpersist: per-key concurrency limit must be positive, got %d", limit))
+	}
+	var (
+		mu   sync.Mutex
+		sems = make(map[string]chan struct{})
+	)
+
+	acquire := func(key string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		sem, ok := sems[key]
+		if !ok {
+			sem = make(chan struct{}, limit)
+		}
+		select {
+		case sem <- struct{}{}:
+			sems[key] = sem
+			return true
+		default:
+			return false
+		}
+	}
+
+	release := func(key string) {
+		mu.Lock()
+		defer mu.Unlock()
+		sem := sems[key]
+		<-sem
+		if len(sem) == 0 {
+			delete(sems, key)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key := keyFunc(req)
+			if !acquire(key) {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			defer release(key)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+/*
+Returns a middleware that serves at most maxConcurrent requests at a time.
+Requests arriving while all slots are taken wait in a queue of up to maxQueue
+requests for at most maxWait. Requests that find the queue full, or whose
+wait expires, are rejected with 503 Service Unavailable; requests whose
+client goes away while waiting are dropped. A maxQueue of zero rejects
+requests as soon as all slots are taken. It panics if maxConcurrent is not
+positive or if maxQueue or maxWait is negative.
+
+	r.Use(router.QueueMiddleware(64, 256, 2*time.Second))
+*/
+func QueueMiddleware(maxConcurrent, maxQueue int, maxWait time.Duration) func(http.Handler) http.Handler {
+	switch {
+	case maxConcurrent <= 0:
+		panic(fmt.Sprintf("httprouterpersist: queue concurrency must be positive, got %d", maxConcurrent))
+	case maxQueue < 0:
+		panic(fmt.Sprintf("httprouterpersist: queue length must not be negative, got %d", maxQueue))
+	case maxWait < 0:
+		panic(fmt.Sprintf("httprouterpersist: queue wait must not be negative, got %v", maxWait))
+	}
+	slots := make(chan struct{}, maxConcurrent)
+	queue := make(chan struct{}, maxQueue)
+
+	unavailable := func(w http.ResponseWriter) {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				select {
+				case queue <- struct{}{}:
+				default:
+					unavailable(w)
+					return
+				}
+				timer := time.NewTimer(maxWait)
+				select {
+				case slots <- struct{}{}:
+					timer.Stop()
+					<-queue
+				case <-timer.C:
+					<-queue
+					unavailable(w)
+					return
+				case <-req.Context().Done():
+					timer.Stop()
+					<-queue
+					return
+				}
+			}
+			defer func() { <-slots }()
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPerKeyConcurrencyMiddleware(t *testing.T) {
+	const limit = 2
+	var (
+		started sync.WaitGroup
+		release = make(chan struct{})
+	)
+	r := New()
+	r.Use(PerKeyConcurrencyMiddleware(limit, func(req *http.Request) string {
+		return req.Header.Get("X-API-Key")
+	}))
+	r.GET("/slow", func(w http.ResponseWriter, req *http.Request) {
+		started.Done()
+		<-release
+	})
+	r.GET("/fast", func(w http.ResponseWriter, req *http.Request) {})
+
+	get := func(path, key string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	var done sync.WaitGroup
+	started.Add(limit)
+	for i := 0; i < limit; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			if code := get("/slow", "a"); code != http.StatusOK {
+				t.Errorf("saturating request = %d, want 200", code)
+			}
+		}()
+	}
+	started.Wait()
+
+	if code := get("/fast", "a"); code != http.StatusTooManyRequests {
+		t.Errorf("request over the limit for key a = %d, want 429", code)
+	}
+	if code := get("/fast", "b"); code != http.StatusOK {
+		t.Errorf("request for key b = %d, want 200", code)
+	}
+	close(release)
+	done.Wait()
+
+	if code := get("/fast", "a"); code != http.StatusOK {
+		t.Errorf("request for key a after release = %d, want 200", code)
+	}
+}
+
+func TestPerKeyConcurrencyMiddlewareInvalidLimit(t *testing.T) {
+	for _, limit := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("limit %d: expected panic", limit)
+				}
+			}()
+			PerKeyConcurrencyMiddleware(limit, func(*http.Request) string { return "" })
+		}()
+	}
+}
+
+func TestQueueMiddleware(t *testing.T) {
+	newRouter := func(maxWait time.Duration) (*Router, chan struct{}, chan struct{}) {
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+		r := New()
+		r.Use(QueueMiddleware(1, 1, maxWait))
+		r.GET("/work", func(w http.ResponseWriter, req *http.Request) {
+			started <- struct{}{}
+			<-release
+		})
+		return r, started, release
+	}
+	async := func(r *Router) chan int {
+		code := make(chan int, 1)
+		go func() { code <- serve(r, "GET", "/work").Code }()
+		return code
+	}
+
+	// A queued request is admitted once the slot frees up, while a request
+	// arriving with the queue full is rejected straight away.
+	r, started, release := newRouter(time.Second)
+	first := async(r)
+	<-started
+	queued := async(r)
+	time.Sleep(50 * time.Millisecond)
+	if code := serve(r, "GET", "/work").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("request with a full queue = %d, want 503", code)
+	}
+	release <- struct{}{}
+	<-started
+	close(release)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first request = %d, want 200", code)
+	}
+	if code := <-queued; code != http.StatusOK {
+		t.Errorf("queued request = %d, want 200", code)
+	}
+
+	// A queued request whose maxWait expires is rejected.
+	r, started, release = newRouter(20 * time.Millisecond)
+	first = async(r)
+	<-started
+	if code := serve(r, "GET", "/work").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("request after maxWait = %d, want 503", code)
+	}
+	close(release)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first request = %d, want 200", code)
+	}
+}
+
+func TestQueueMiddlewareInvalidLimits(t *testing.T) {
+	tests := []struct {
+		maxConcurrent, maxQueue int
+		maxWait                 time.Duration
+	}{
+		{0, 1, time.Second},
+		{-1, 1, time.Second},
+		{1, -1, time.Second},
+		{1, 1, -time.Second},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("QueueMiddleware(%d, %d, %v): expected panic", tt.maxConcurrent, tt.maxQueue, tt.maxWait)
+				}
+			}()
+			QueueMiddleware(tt.maxConcurrent, tt.maxQueue, tt.maxWait)
+		}()
+	}
+	QueueMiddleware(1, 0, 0)
+}
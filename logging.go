@@ -0,0 +1,162 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/*
+Returns a middleware that logs a warning for every request whose handler takes
+longer than threshold. Requests that finish in time are not logged at all. The
+log entry includes the matched route template, the status and the duration.
+If logger is nil, slog.Default() is used.
+
+	r := router.New()
+	handler := router.SlowRequestLogMiddleware(500*time.Millisecond, nil)(r)
+*/
+func SlowRequestLogMiddleware(threshold time.Duration, logger *slog.Logger) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			withState(req, func(req *http.Request, s *requestState) {
+				start := startTime(req)
+				sw := newStatusWriter(w)
+				next.ServeHTTP(sw, req)
+
+				d := time.Since(start)
+				if d <= threshold {
+					return
+				}
+				logger.Warn("slow request",
+					"method", req.Method,
+					"path", req.URL.Path,
+					"route", s.routePath(),
+					"status", sw.status,
+					"duration", d,
+				)
+			})
+		})
+	}
+}
+
+/*
+Returns a middleware that attaches logger to every request, so that handlers
+can log through Logger. Once a route matches, its params are added to the
+logger as attributes, subject to the router's LogParamFilter:
+
+	r.LogParamFilter = func(key string) bool { return key != "token" }
+	r.Use(router.LoggerMiddleware(slog.Default()))
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		router.Logger(req).Info("showing user") // includes id=...
+	})
+*/
+func LoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			withState(req, func(req *http.Request, s *requestState) {
+				s.logger = logger
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Returns the logger attached to r by LoggerMiddleware, or slog.Default() if
+there is none.
+*/
+func Logger(r *http.Request) *slog.Logger {
+	if s := stateFrom(r); s != nil && s.logger != nil {
+		return s.logger
+	}
+	return slog.Default()
+}
+
+/*
+Returns the params that pass LogParamFilter as logger arguments.
+*/
+func (r *Router) paramAttrs(ps httprouter.Params) []interface{} {
+	attrs := make([]interface{}, 0, len(ps))
+	for _, p := range ps {
+		if r.LogParamFilter == nil || r.LogParamFilter(p.Key) {
+			attrs = append(attrs, slog.String(p.Key, p.Value))
+		}
+	}
+	return attrs
+}
+
+/*
+Returns a middleware that writes an access log line in the Common Log Format
+to out for every request:
+
+	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+*/
+func CLFLogMiddleware(out io.Writer) func(http.Handler) http.Handler {
+	return accessLogMiddleware(out, false)
+}
+
+/*
+Like CLFLogMiddleware, but writes the Combined Log Format, which adds the
+Referer and User-Agent request headers.
+*/
+func CombinedLogMiddleware(out io.Writer) func(http.Handler) http.Handler {
+	return accessLogMiddleware(out, true)
+}
+
+func accessLogMiddleware(out io.Writer, combined bool) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := startTime(req)
+			sw := newStatusWriter(w)
+			next.ServeHTTP(sw, req)
+
+			line := clfLine(req, start, sw.status, sw.bytes)
+			if combined {
+				line += fmt.Sprintf(" %q %q", clfField(req.Referer()), clfField(req.UserAgent()))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			io.WriteString(out, line+"\n")
+		})
+	}
+}
+
+func clfLine(req *http.Request, start time.Time, status, bytes int) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	user := "-"
+	if u, _, ok := req.BasicAuth(); ok && u != "" {
+		user = u
+	}
+	uri := req.RequestURI
+	if uri == "" {
+		uri = req.URL.RequestURI()
+	}
+	size := "-"
+	if bytes > 0 {
+		size = strconv.Itoa(bytes)
+	}
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+		clfField(host), user, start.Format("02/Jan/2006:15:04:05 -0700"),
+		req.Method, uri, req.Proto, status, size)
+}
+
+func clfField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
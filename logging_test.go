@@ -0,0 +1,100 @@
+package httprouterpersist
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowRequestLogMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	r := New()
+	r.Use(SlowRequestLogMiddleware(20*time.Millisecond, logger))
+	r.GET("/fast", func(w http.ResponseWriter, req *http.Request) {})
+	r.GET("/slow/:id", func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(40 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+	if buf.Len() != 0 {
+		t.Fatalf("fast request logged %q", buf.String())
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow/1", nil))
+	line := buf.String()
+	for _, want := range []string{"level=WARN", "route=/slow/:id", "status=202"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("log line %q does not contain %q", line, want)
+		}
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	const clf = `^192\.0\.2\.1 - alice \[\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /users/42\?full=1 HTTP/1\.1" 201 5`
+	for name, tc := range map[string]struct {
+		mw   func(io.Writer) func(http.Handler) http.Handler
+		want string
+	}{
+		"clf":      {CLFLogMiddleware, clf + "\n$"},
+		"combined": {CombinedLogMiddleware, clf + ` "https://example\.com/" "test-agent/1\.0"\n$`},
+	} {
+		var buf bytes.Buffer
+		r := New()
+		r.Use(tc.mw(&buf))
+		r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("hello"))
+		})
+
+		req := httptest.NewRequest("GET", "/users/42?full=1", nil)
+		req.SetBasicAuth("alice", "secret")
+		req.Header.Set("Referer", "https://example.com/")
+		req.Header.Set("User-Agent", "test-agent/1.0")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !regexp.MustCompile(tc.want).MatchString(buf.String()) {
+			t.Errorf("%s: log line %q does not match %s", name, buf.String(), tc.want)
+		}
+	}
+}
+
+func TestAccessLogMiddlewareEmptyFields(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Use(CombinedLogMiddleware(&buf))
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Del("User-Agent")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if !strings.HasSuffix(buf.String(), `"GET /missing HTTP/1.1" 404 19 "-" "-"`+"\n") {
+		t.Errorf("log line = %q", buf.String())
+	}
+}
+
+func TestLoggerMiddlewareParams(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.LogParamFilter = func(key string) bool { return key != "token" }
+	r.Use(LoggerMiddleware(slog.New(slog.NewTextHandler(&buf, nil))))
+	r.GET("/users/:id/invites/:token", func(w http.ResponseWriter, req *http.Request) {
+		Logger(req).Info("accepting invite")
+	})
+
+	serve(r, "GET", "/users/42/invites/s3cret")
+	line := buf.String()
+	if !strings.Contains(line, "msg=\"accepting invite\"") || !strings.Contains(line, "id=42") {
+		t.Errorf("log line %q does not include the message and id=42", line)
+	}
+	if strings.Contains(line, "token") || strings.Contains(line, "s3cret") {
+		t.Errorf("log line %q includes the filtered token param", line)
+	}
+}
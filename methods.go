@@ -0,0 +1,143 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+/*
+Routes requests whose path matches a route but whose method does not to h
+instead of answering them with 405 Method Not Allowed. h runs inside the
+global middleware and can read the request method as usual and the methods
+that are registered for the path with AllowedMethods. This suits proxies
+that forward any method, including unknown verbs, upstream:
+
+	r.SetMethodNotAllowedFallback(func(w http.ResponseWriter, r *http.Request) {
+		proxy.ServeHTTP(w, r)
+	})
+*/
+func (r *Router) SetMethodNotAllowedFallback(h http.HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.HandleMethodNotAllowed = true
+	r.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		withState(req, func(req *http.Request, s *requestState) {
+			s.allowed = r.allowedMethods(req.URL.Path, req.Method)
+			if len(s.allowed) == 0 {
+				w.Header().Del("Allow")
+				r.notFound(w, req)
+				return
+			}
+			h(w, req)
+		})
+	})
+}
+
+/*
+Returns the methods registered for the path of a request that was routed to
+the method-not-allowed fallback, or nil for any other request.
+*/
+func AllowedMethods(req *http.Request) []string {
+	if s := stateFrom(req); s != nil {
+		return s.allowed
+	}
+	return nil
+}
+
+/*
+Answers requests whose path matches a route but whose method does not with a
+405 Method Not Allowed. The Allow header lists the methods registered for the
+path in sorted order, computed from the tracked routes so that it is stable
+and reflects the method the request ended up with, e.g. after
+MethodOverrideMiddleware. A path that only matches reserved trailing-slash
+variants has no methods and gets the NotFound handler instead.
+*/
+func (r *Router) methodNotAllowed(w http.ResponseWriter, req *http.Request) {
+	allowed := r.allowedMethods(req.URL.Path, req.Method)
+	if len(allowed) == 0 {
+		w.Header().Del("Allow")
+		r.notFound(w, req)
+		return
+	}
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+/*
+Returns a middleware that lets clients that can only send GET and POST, such
+as HTML forms, reach PUT, PATCH and DELETE routes. A POST request with an
+X-HTTP-Method-Override header or a _method query or form value naming one of
+those methods is routed as that method. Other requests and methods are left
+alone. The method sent by the client can be read with OriginalMethod.
+
+	<form method="POST" action="/posts/42?_method=DELETE">
+
+Install it with Use so that it runs before routing. If the path has no route
+for the overridden method the 405 response lists the methods the path does
+have.
+*/
+func MethodOverrideMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodPost {
+				next.ServeHTTP(w, req)
+				return
+			}
+			method := req.Header.Get("X-HTTP-Method-Override")
+			if method == "" {
+				method = req.FormValue("_method")
+			}
+			switch method = strings.ToUpper(method); method {
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, req)
+				return
+			}
+			withState(req, func(req *http.Request, s *requestState) {
+				s.originalMethod = req.Method
+				req.Method = method
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Returns the method the client sent, before any rewrite by
+MethodOverrideMiddleware.
+*/
+func OriginalMethod(req *http.Request) string {
+	if s := stateFrom(req); s != nil && s.originalMethod != "" {
+		return s.originalMethod
+	}
+	return req.Method
+}
+
+/*
+Returns the sorted methods other than method that have a route matching
+path. OPTIONS is included, as httprouter does, when HandleOPTIONS is set and
+no OPTIONS route matches path already. Reserved trailing-slash variants do not
+count.
+*/
+func (r *Router) allowedMethods(path, method string) []string {
+	seen := make(map[string]bool)
+	var allowed []string
+	options := false
+	for _, rt := range r.routeList() {
+		m := rt.Method
+		if m == method || seen[m] {
+			continue
+		}
+		seen[m] = true
+		if handle, ps, _ := r.Router.Lookup(m, path); handle != nil && !r.isReserved(m, path, ps) {
+			allowed = append(allowed, m)
+			options = options || m == http.MethodOptions
+		}
+	}
+	if len(allowed) > 0 && r.HandleOPTIONS && !options {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
@@ -0,0 +1,58 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSetMethodNotAllowedFallback(t *testing.T) {
+	r := New()
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+	r.POST("/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+	r.SetMethodNotAllowedFallback(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(req.Method + " " + strings.Join(AllowedMethods(req), ",")))
+	})
+
+	rec := serve(r, "DELETE", "/users/42")
+	if rec.Code != http.StatusTeapot || rec.Body.String() != "DELETE GET,OPTIONS,POST" {
+		t.Errorf("DELETE /users/42 = %d %q, want the fallback with GET,OPTIONS,POST", rec.Code, rec.Body.String())
+	}
+	if rec := serve(r, "DELETE", "/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE /missing = %d, want 404", rec.Code)
+	}
+	if rec := serve(r, "GET", "/users/42"); rec.Code != http.StatusOK {
+		t.Errorf("GET /users/42 = %d, want 200", rec.Code)
+	}
+}
+
+func TestMethodOverrideNotAllowed(t *testing.T) {
+	r := New()
+	r.Use(MethodOverrideMiddleware())
+	r.GET("/posts/:id", func(w http.ResponseWriter, req *http.Request) {})
+	r.DELETE("/drafts/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.Method + " from " + OriginalMethod(req)))
+	})
+
+	rec := serve(r, "POST", "/posts/42?_method=PATCH")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("POST ?_method=PATCH = %d Allow %q, want 405 with \"GET, OPTIONS\"", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	rec = serve(r, "POST", "/drafts/42?_method=delete")
+	if rec.Code != http.StatusOK || rec.Body.String() != "DELETE from POST" {
+		t.Errorf("POST ?_method=delete = %d %q, want the DELETE route", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAllowedMethodsOptionsElsewhere(t *testing.T) {
+	r := New()
+	r.GET("/a", func(w http.ResponseWriter, req *http.Request) {})
+	r.OPTIONS("/b", func(w http.ResponseWriter, req *http.Request) {})
+
+	rec := serve(r, "POST", "/a")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("POST /a = %d Allow %q, want 405 with \"GET, OPTIONS\"", rec.Code, rec.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,102 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"time"
+)
+
+/*
+The label MetricRouteLabel returns for requests that did not match a route.
+*/
+const UnmatchedRouteLabel = "unmatched"
+
+/*
+Returns a low-cardinality label for the route that served req, suitable for
+metrics. The label is always the registered route template, e.g.
+"/files/*filepath", never the concrete request path. Requests that did not
+match a route are labelled UnmatchedRouteLabel.
+*/
+func (r *Router) MetricRouteLabel(req *http.Request) string {
+	if s := stateFrom(req); s != nil && s.route != nil {
+		return s.route.Path
+	}
+	if rt := r.routeFor(req.Method, req.URL.Path); rt != nil {
+		return rt.Path
+	}
+	return UnmatchedRouteLabel
+}
+
+/*
+Returns a middleware that reports every request to observe with its route
+label, status and duration. The route label comes from MetricRouteLabel, so
+it can be used as a metrics label without exploding cardinality.
+
+	r.Use(r.MetricsMiddleware(func(route string, status int, d time.Duration) {
+		requestDuration.WithLabelValues(route, strconv.Itoa(status)).Observe(d.Seconds())
+	}))
+*/
+func (r *Router) MetricsMiddleware(observe func(route string, status int, d time.Duration)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			withState(req, func(req *http.Request, s *requestState) {
+				start := startTime(req)
+				sw := newStatusWriter(w)
+				next.ServeHTTP(sw, req)
+				observe(r.MetricRouteLabel(req), sw.status, time.Since(start))
+			})
+		})
+	}
+}
+
+/*
+The RouteStats type holds the request counters of a single route template.
+Errors counts responses with a status of 500 or above. LatencySum divided by
+LatencyCount is the mean latency of the route.
+*/
+type RouteStats struct {
+	Requests     int64
+	Errors       int64
+	LatencySum   time.Duration
+	LatencyCount int64
+}
+
+/*
+Returns a middleware that accumulates the per-route counters reported by
+Stats. Install it with Use to count every request:
+
+	r.Use(r.StatsMiddleware())
+*/
+func (r *Router) StatsMiddleware() func(http.Handler) http.Handler {
+	return r.MetricsMiddleware(func(route string, status int, d time.Duration) {
+		r.statsMu.Lock()
+		defer r.statsMu.Unlock()
+		if r.stats == nil {
+			r.stats = make(map[string]*RouteStats)
+		}
+		st, ok := r.stats[route]
+		if !ok {
+			st = &RouteStats{}
+			r.stats[route] = st
+		}
+		st.Requests++
+		if status >= 500 {
+			st.Errors++
+		}
+		st.LatencySum += d
+		st.LatencyCount++
+	})
+}
+
+/*
+Returns a snapshot of the counters collected by StatsMiddleware, keyed by
+route template as returned by MetricRouteLabel.
+*/
+func (r *Router) Stats() map[string]RouteStats {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	stats := make(map[string]RouteStats, len(r.stats))
+	for route, st := range r.stats {
+		stats[route] = *st
+	}
+	return stats
+}
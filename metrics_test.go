@@ -0,0 +1,53 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMetricRouteLabel(t *testing.T) {
+	r := New()
+	var labels []string
+	r.Use(r.MetricsMiddleware(func(route string, status int, d time.Duration) {
+		labels = append(labels, route)
+	}))
+	r.GET("/files/*filepath", func(w http.ResponseWriter, req *http.Request) {})
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+
+	for _, path := range []string{"/files/a.txt", "/files/docs/2024/report.pdf", "/users/42", "/missing"} {
+		serve(r, "GET", path)
+	}
+	want := []string{"/files/*filepath", "/files/*filepath", "/users/:id", UnmatchedRouteLabel}
+	for i := range want {
+		if i >= len(labels) || labels[i] != want[i] {
+			t.Fatalf("labels = %v, want %v", labels, want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	r := New()
+	r.Use(r.StatsMiddleware())
+	r.GET("/orders/:id", func(w http.ResponseWriter, req *http.Request) {
+		if Param(req, "id") == "bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	r.GET("/health", func(w http.ResponseWriter, req *http.Request) {})
+
+	for _, path := range []string{"/orders/1", "/orders/bad", "/orders/2", "/orders/bad", "/orders/3", "/health", "/missing"} {
+		serve(r, "GET", path)
+	}
+
+	stats := r.Stats()
+	if st := stats["/orders/:id"]; st.Requests != 5 || st.Errors != 2 || st.LatencyCount != 5 || st.LatencySum <= 0 {
+		t.Errorf("/orders/:id stats = %+v, want 5 requests and 2 errors", st)
+	}
+	if st := stats["/health"]; st.Requests != 1 || st.Errors != 0 {
+		t.Errorf("/health stats = %+v, want 1 request and no errors", st)
+	}
+	if st := stats[UnmatchedRouteLabel]; st.Requests != 1 {
+		t.Errorf("unmatched stats = %+v, want 1 request", st)
+	}
+}
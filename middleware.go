@@ -0,0 +1,121 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+/*
+The namedMiddleware type pairs a middleware with the name it is reported
+under by MiddlewareFor.
+*/
+type namedMiddleware struct {
+	name string
+	fn   func(http.Handler) http.Handler
+}
+
+/*
+Appends global middleware that wrap every request served by the router,
+including requests that end up in the NotFound or MethodNotAllowed handlers.
+Middleware run in the order they are added. Each middleware is named after
+its function; use UseNamed to give it an explicit name.
+*/
+func (r *Router) Use(mw ...func(http.Handler) http.Handler) {
+	for _, fn := range mw {
+		r.UseNamed(middlewareName(fn), fn)
+	}
+}
+
+/*
+Appends a global middleware under the given name.
+
+	r.UseNamed("recovery", RecoveryMiddleware)
+	r.UseNamed("logging", LoggingMiddleware)
+*/
+func (r *Router) UseNamed(name string, mw func(http.Handler) http.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, namedMiddleware{name, mw})
+	r.handler = r.chain(r.middleware, http.HandlerFunc(r.dispatch))
+}
+
+/*
+Returns the ordered names of the middleware that wrap the handler matched by
+method and path: the global middleware first, followed by the group and
+route middleware. Returns nil if no route matches.
+*/
+func (r *Router) MiddlewareFor(method, path string) []string {
+	rt := r.routeFor(method, path)
+	if rt == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.middleware)+len(rt.middleware))
+	for _, m := range r.middleware {
+		names = append(names, m.name)
+	}
+	for _, m := range rt.middleware {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+/*
+Returns an error if the names of the global middleware, in the order they
+were added with Use and UseNamed, are not exactly expected. The error names
+the first position that differs and both orders, so it can be reported as is
+by a test:
+
+	if err := r.AssertMiddlewareOrder([]string{"recovery", "logging", "auth"}); err != nil {
+		t.Fatal(err)
+	}
+*/
+func (r *Router) AssertMiddlewareOrder(expected []string) error {
+	r.mu.RLock()
+	actual := make([]string, len(r.middleware))
+	for i, m := range r.middleware {
+		actual[i] = m.name
+	}
+	r.mu.RUnlock()
+
+	for i := 0; i < len(actual) || i < len(expected); i++ {
+		switch {
+		case i >= len(expected):
+			return fmt.Errorf("httprouterpersist: unexpected middleware %q at position %d; got %v, want %v", actual[i], i, actual, expected)
+		case i >= len(actual):
+			return fmt.Errorf("httprouterpersist: missing middleware %q at position %d; got %v, want %v", expected[i], i, actual, expected)
+		case actual[i] != expected[i]:
+			return fmt.Errorf("httprouterpersist: middleware %q at position %d, want %q; got %v, want %v", actual[i], i, expected[i], actual, expected)
+		}
+	}
+	return nil
+}
+
+/*
+Wraps h with mw so that the first middleware is the outermost. When Profile
+is set each layer is timed.
+*/
+func (r *Router) chain(mw []namedMiddleware, h http.Handler) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i].fn(h)
+		if r.Profile {
+			h = timed(mw[i].name, h)
+		}
+	}
+	return h
+}
+
+/*
+Returns the unqualified function name of fn, e.g. "httprouterpersist.Foo".
+*/
+func middlewareName(fn func(http.Handler) http.Handler) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
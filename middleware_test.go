@@ -0,0 +1,67 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func passThrough(next http.Handler) http.Handler {
+	return next
+}
+
+func TestMiddlewareFor(t *testing.T) {
+	r := New()
+	r.UseNamed("recovery", passThrough)
+	r.UseNamed("logging", passThrough)
+	api := r.Group("/api").WithNamed("auth", passThrough)
+	api.WithNamed("audit", passThrough).GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+	api.GET("/health", func(w http.ResponseWriter, req *http.Request) {})
+
+	want := []string{"recovery", "logging", "auth", "audit"}
+	if got := r.MiddlewareFor("GET", "/api/users/42"); !reflect.DeepEqual(got, want) {
+		t.Errorf("MiddlewareFor(/api/users/42) = %v, want %v", got, want)
+	}
+	want = []string{"recovery", "logging", "auth"}
+	if got := r.MiddlewareFor("GET", "/api/health"); !reflect.DeepEqual(got, want) {
+		t.Errorf("MiddlewareFor(/api/health) = %v, want %v", got, want)
+	}
+	if got := r.MiddlewareFor("GET", "/missing"); got != nil {
+		t.Errorf("MiddlewareFor(/missing) = %v, want nil", got)
+	}
+}
+
+func TestMiddlewareForFunctionNames(t *testing.T) {
+	r := New()
+	r.Use(passThrough)
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) {})
+
+	if got := r.MiddlewareFor("GET", "/"); len(got) != 1 || got[0] != "httprouterpersist.passThrough" {
+		t.Errorf("MiddlewareFor(/) = %v", got)
+	}
+}
+
+func TestAssertMiddlewareOrder(t *testing.T) {
+	r := New()
+	r.UseNamed("recovery", passThrough)
+	r.UseNamed("logging", passThrough)
+	r.UseNamed("auth", passThrough)
+
+	if err := r.AssertMiddlewareOrder([]string{"recovery", "logging", "auth"}); err != nil {
+		t.Errorf("matching order: %v", err)
+	}
+	for _, tc := range []struct {
+		expected []string
+		want     string
+	}{
+		{[]string{"logging", "recovery", "auth"}, `middleware "recovery" at position 0, want "logging"`},
+		{[]string{"recovery", "logging"}, `unexpected middleware "auth" at position 2`},
+		{[]string{"recovery", "logging", "auth", "metrics"}, `missing middleware "metrics" at position 3`},
+	} {
+		err := r.AssertMiddlewareOrder(tc.expected)
+		if err == nil || !strings.Contains(err.Error(), tc.want) || !strings.Contains(err.Error(), "got [recovery logging auth]") {
+			t.Errorf("AssertMiddlewareOrder(%v) = %v, want an error containing %q and the actual order", tc.expected, err, tc.want)
+		}
+	}
+}
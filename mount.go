@@ -0,0 +1,74 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/*
+Registers the router on mux for every path under prefix. The prefix is
+stripped before routing, so routes are registered without it:
+
+	r.GET("/users", ListUsers)
+	r.IntoServeMux(mux, "/api/") // serves "/api/users"
+*/
+func (r *Router) IntoServeMux(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, r))
+}
+
+/*
+Mounts h under prefix for every common method. Requests for prefix and any
+path below it are passed to h with the prefix stripped from the url path;
+h can read the part of the path it is responsible for with Remainder, even
+when it is nested in further mounts.
+
+	r.Mount("/admin", adminRouter)
+*/
+func (r *Router) Mount(prefix string, h http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	mounted := func(w http.ResponseWriter, req *http.Request) {
+		withState(req, func(req *http.Request, s *requestState) {
+			if s.mountPrefix == "" {
+				s.originalPath = req.URL.Path
+			}
+			s.mountPrefix += prefix
+
+			rest := Param(req, "mountpath")
+			if rest == "" {
+				rest = "/"
+			}
+			r2 := new(http.Request)
+			*r2 = *req
+			r2.URL = new(url.URL)
+			*r2.URL = *req.URL
+			r2.URL.Path = rest
+			r2.URL.RawPath = ""
+			h.ServeHTTP(w, r2)
+		})
+	}
+
+	for _, method := range []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"} {
+		if prefix != "" {
+			r.Handle(method, prefix, mounted)
+		}
+		r.Handle(method, prefix+"/*mountpath", mounted)
+	}
+}
+
+/*
+Returns the part of the request path that was not consumed by the prefixes
+of the mounts the request passed through, or the whole path if it was not
+mounted. A request for the mount prefix itself has the remainder "/".
+*/
+func Remainder(r *http.Request) string {
+	s := stateFrom(r)
+	if s == nil || s.mountPrefix == "" {
+		return r.URL.Path
+	}
+	if rest := strings.TrimPrefix(s.originalPath, s.mountPrefix); rest != "" {
+		return rest
+	}
+	return "/"
+}
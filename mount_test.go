@@ -0,0 +1,58 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIntoServeMux(t *testing.T) {
+	r := New()
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Path + " " + Param(req, "id")))
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {})
+	r.IntoServeMux(mux, "/api/")
+
+	rec := serve(mux, "GET", "/api/users/42")
+	if rec.Code != http.StatusOK || rec.Body.String() != "/users/42 42" {
+		t.Errorf("GET /api/users/42 = %d %q, want 200 \"/users/42 42\"", rec.Code, rec.Body.String())
+	}
+	if rec := serve(mux, "GET", "/users/42"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /users/42 = %d, want 404", rec.Code)
+	}
+	if rec := serve(mux, "GET", "/health"); rec.Code != http.StatusOK {
+		t.Errorf("GET /health = %d, want 200", rec.Code)
+	}
+}
+
+func TestMountRemainder(t *testing.T) {
+	var remainder, path string
+	leaf := func(w http.ResponseWriter, req *http.Request) {
+		remainder, path = Remainder(req), req.URL.Path
+	}
+	reports := New()
+	reports.GET("/*rest", leaf)
+	admin := New()
+	admin.Mount("/reports", reports)
+	admin.GET("/users", leaf)
+	r := New()
+	r.Mount("/admin", admin)
+	r.GET("/top", leaf)
+
+	for url, want := range map[string]string{
+		"/admin/reports/2024/q3": "/2024/q3",
+		"/admin/reports":         "/",
+		"/admin/users":           "/users",
+		"/top":                   "/top",
+	} {
+		remainder, path = "", ""
+		if rec := serve(r, "GET", url); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", url, rec.Code)
+			continue
+		}
+		if remainder != want || path != want {
+			t.Errorf("GET %s: Remainder = %q, URL.Path = %q, want %q", url, remainder, path, want)
+		}
+	}
+}
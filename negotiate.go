@@ -0,0 +1,169 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Returns the offered media type that best matches the request Accept header,
+or an empty string if none of the offers are acceptable. Quality values and
+wildcards are honoured, with more specific media ranges taking precedence
+over less specific ones. Ties are resolved in favour of the earlier offer. A
+request without an Accept header accepts the first offer.
+
+	switch router.Negotiate(r, "application/json", "text/html") {
+	case "application/json":
+		...
+	}
+*/
+func Negotiate(r *http.Request, offers ...string) string {
+	header := r.Header.Get("Accept")
+	if header == "" {
+		if len(offers) > 0 {
+			return offers[0]
+		}
+		return ""
+	}
+	ranges := parseAccept(header)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q := acceptQuality(ranges, offer)
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+/*
+The acceptRange type is a single media range of an Accept header.
+*/
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		typ, subtype, _ := strings.Cut(strings.ToLower(strings.TrimSpace(fields[0])), "/")
+		if typ == "" {
+			continue
+		}
+		if subtype == "" {
+			subtype = "*"
+		}
+		ar := acceptRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range fields[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					ar.q = q
+				}
+			}
+		}
+		ranges = append(ranges, ar)
+	}
+	return ranges
+}
+
+/*
+Returns the quality of offer according to the most specific matching range.
+*/
+func acceptQuality(ranges []acceptRange, offer string) float64 {
+	typ, subtype, _ := strings.Cut(strings.ToLower(offer), "/")
+	q, specificity := 0.0, -1
+	for _, ar := range ranges {
+		s := -1
+		switch {
+		case ar.typ == typ && ar.subtype == subtype:
+			s = 2
+		case ar.typ == typ && ar.subtype == "*":
+			s = 1
+		case ar.typ == "*" && ar.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = ar.q, s
+		}
+	}
+	return q
+}
+
+/*
+Registers a GET route that dispatches to one of handlers based on the request
+Accept header. handlers is keyed by media type. Requests that accept none of
+the media types get a 406 Not Acceptable.
+
+	r.GETAccept("/users/:id", map[string]http.HandlerFunc{
+		"application/json": ShowUserJSON,
+		"text/html":        ShowUserHTML,
+	})
+*/
+func (r *Router) GETAccept(path string, handlers map[string]http.HandlerFunc) *Route {
+	offers := make([]string, 0, len(handlers))
+	for mediaType := range handlers {
+		offers = append(offers, mediaType)
+	}
+	sort.Strings(offers)
+
+	return r.GET(path, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		mediaType := Negotiate(req, offers...)
+		if mediaType == "" {
+			http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+			return
+		}
+		handlers[mediaType](w, req)
+	})
+}
+
+/*
+Registers a GET route that dispatches to one of handlers based on the version
+parameter of the request Accept header, as in
+"application/vnd.api+json;version=2". handlers is keyed by version; the
+handler under the empty key is the default, used for requests without a
+version and for versions that have no handler. Without a default those
+requests get a 406 Not Acceptable.
+
+	r.GETVersioned("/users/:id", map[string]http.HandlerFunc{
+		"1": ShowUserV1,
+		"2": ShowUserV2,
+		"":  ShowUserV2,
+	})
+*/
+func (r *Router) GETVersioned(path string, handlers map[string]http.HandlerFunc) *Route {
+	return r.GET(path, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		fn, ok := handlers[acceptVersion(req.Header.Get("Accept"))]
+		if !ok {
+			fn, ok = handlers[""]
+		}
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+			return
+		}
+		fn(w, req)
+	})
+}
+
+/*
+Returns the value of the first version parameter in an Accept header, or an
+empty string if there is none.
+*/
+func acceptVersion(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		for _, param := range strings.Split(part, ";")[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(k, "version") {
+				return strings.Trim(v, `"`)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveAccept(r http.Handler, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGETAccept(t *testing.T) {
+	r := New()
+	r.GETAccept("/users/:id", map[string]http.HandlerFunc{
+		"application/json": func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("json " + Param(req, "id"))) },
+		"text/html":        func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("html " + Param(req, "id"))) },
+	})
+
+	for accept, want := range map[string]string{
+		"application/json":                  "json 42",
+		"text/html;q=0.5, application/json": "json 42",
+		"text/html, application/json;q=0.9": "html 42",
+		"text/*, application/json;q=0.1":    "html 42",
+	} {
+		rec := serveAccept(r, "/users/42", accept)
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("Accept %q = %d %q, want %q", accept, rec.Code, rec.Body.String(), want)
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: Vary = %q", accept, rec.Header().Get("Vary"))
+		}
+	}
+	if rec := serveAccept(r, "/users/42", "image/png"); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("Accept image/png = %d, want 406", rec.Code)
+	}
+}
+
+func TestGETVersioned(t *testing.T) {
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) { w.Write([]byte(name)) }
+	}
+	withDefault := New()
+	withDefault.GETVersioned("/users/:id", map[string]http.HandlerFunc{
+		"1": handler("v1"),
+		"2": handler("v2"),
+		"":  handler("default"),
+	})
+	withoutDefault := New()
+	withoutDefault.GETVersioned("/users/:id", map[string]http.HandlerFunc{
+		"1": handler("v1"),
+		"2": handler("v2"),
+	})
+
+	for accept, want := range map[string]string{
+		"application/vnd.api+json;version=1":    "v1",
+		`application/vnd.api+json; version="2"`: "v2",
+		"application/vnd.api+json;version=9":    "default",
+		"application/json":                      "default",
+	} {
+		rec := serveAccept(withDefault, "/users/42", accept)
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("with default: Accept %q = %d %q, want %q", accept, rec.Code, rec.Body.String(), want)
+		}
+	}
+
+	if rec := serveAccept(withoutDefault, "/users/42", "application/vnd.api+json;version=2"); rec.Body.String() != "v2" {
+		t.Errorf("without default: version 2 = %q, want v2", rec.Body.String())
+	}
+	for _, accept := range []string{"application/vnd.api+json;version=9", ""} {
+		if rec := serveAccept(withoutDefault, "/users/42", accept); rec.Code != http.StatusNotAcceptable {
+			t.Errorf("without default: Accept %q = %d, want 406", accept, rec.Code)
+		}
+	}
+}
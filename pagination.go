@@ -0,0 +1,73 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"strconv"
+)
+
+/*
+The Pagination type holds the pagination params parsed by
+PaginationMiddleware.
+*/
+type Pagination struct {
+	Page    int
+	PerPage int
+	Offset  int
+}
+
+/*
+Returns a middleware that parses the page and per_page query params of every
+request. Missing params default to page 1 and defaultPerPage, and per_page is
+clamped to maxPerPage. Requests with a zero, negative or non-numeric page or
+per_page get a 400 Bad Request. Handlers read the result with GetPagination:
+
+	r.With(router.PaginationMiddleware(20, 100)).GET("/users", func(w http.ResponseWriter, r *http.Request) {
+		p := router.GetPagination(r)
+		users, total := store.List(p.Offset, p.PerPage)
+		router.WritePaginated(w, r, users, p.Page, p.PerPage, total)
+	})
+*/
+func PaginationMiddleware(defaultPerPage, maxPerPage int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			values := req.URL.Query()
+			page, ok := positiveParam(values.Get("page"), 1)
+			if !ok {
+				http.Error(w, "invalid page", http.StatusBadRequest)
+				return
+			}
+			perPage, ok := positiveParam(values.Get("per_page"), defaultPerPage)
+			if !ok {
+				http.Error(w, "invalid per_page", http.StatusBadRequest)
+				return
+			}
+			if maxPerPage > 0 && perPage > maxPerPage {
+				perPage = maxPerPage
+			}
+
+			withState(req, func(req *http.Request, s *requestState) {
+				s.pagination = &Pagination{Page: page, PerPage: perPage, Offset: (page - 1) * perPage}
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Returns the pagination params parsed by PaginationMiddleware, or the zero
+Pagination if the middleware did not run.
+*/
+func GetPagination(r *http.Request) Pagination {
+	if s := stateFrom(r); s != nil && s.pagination != nil {
+		return *s.pagination
+	}
+	return Pagination{}
+}
+
+func positiveParam(s string, def int) (int, bool) {
+	if s == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n > 0
+}
@@ -0,0 +1,35 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPaginationMiddleware(t *testing.T) {
+	var got Pagination
+	r := New()
+	r.With(PaginationMiddleware(20, 100)).GET("/users", func(w http.ResponseWriter, req *http.Request) {
+		got = GetPagination(req)
+	})
+
+	for path, want := range map[string]Pagination{
+		"/users":                     {Page: 1, PerPage: 20, Offset: 0},
+		"/users?page=3":              {Page: 3, PerPage: 20, Offset: 40},
+		"/users?page=2&per_page=50":  {Page: 2, PerPage: 50, Offset: 50},
+		"/users?page=2&per_page=500": {Page: 2, PerPage: 100, Offset: 100},
+	} {
+		got = Pagination{}
+		if rec := serve(r, "GET", path); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", path, rec.Code)
+		}
+		if got != want {
+			t.Errorf("GET %s: pagination = %+v, want %+v", path, got, want)
+		}
+	}
+
+	for _, path := range []string{"/users?page=0", "/users?page=-1", "/users?page=two", "/users?per_page=0", "/users?per_page=-5", "/users?per_page=x"} {
+		if rec := serve(r, "GET", path); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", path, rec.Code)
+		}
+	}
+}
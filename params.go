@@ -0,0 +1,51 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/*
+Returns the value of the url param matched by the router, or an empty string
+if there is no such param. The params are available regardless of the
+Persist function that is used.
+
+	r.GET("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "User ID: %s", router.Param(r, "id"))
+	})
+*/
+func Param(r *http.Request, name string) string {
+	if s := stateFrom(r); s != nil {
+		return s.params.ByName(name)
+	}
+	return ""
+}
+
+/*
+Returns the url param matched by the router converted to an int.
+*/
+func ParamInt(r *http.Request, name string) (int, error) {
+	return strconv.Atoi(Param(r, name))
+}
+
+/*
+Returns a copy of r carrying params as if they had been matched by the
+router, so that handlers using Param and ParamInt can be tested without
+going through the router.
+
+	req := router.WithParams(httptest.NewRequest("GET", "/users/42", nil), map[string]string{"id": "42"})
+	ShowUser(rec, req)
+*/
+func WithParams(r *http.Request, params map[string]string) *http.Request {
+	ps := make(httprouter.Params, 0, len(params))
+	for k, v := range params {
+		ps = append(ps, httprouter.Param{Key: k, Value: v})
+	}
+	if s := stateFrom(r); s != nil {
+		s.params = ps
+		return r
+	}
+	return (&requestState{params: ps}).attach(r)
+}
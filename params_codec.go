@@ -0,0 +1,87 @@
+package httprouterpersist
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var errShortParams = errors.New("httprouterpersist: truncated params encoding")
+
+/*
+Encodes ps into a compact binary form that can be passed to a queue or
+another process and decoded with UnmarshalParams. The encoding is the number
+of params followed by each key and value, all prefixed with their length as
+uvarints.
+
+	job.Params = router.MarshalParams(ps)
+	...
+	ps, err := router.UnmarshalParams(job.Params)
+*/
+func MarshalParams(ps httprouter.Params) []byte {
+	n := binary.MaxVarintLen64
+	for _, p := range ps {
+		n += 2*binary.MaxVarintLen64 + len(p.Key) + len(p.Value)
+	}
+	b := make([]byte, 0, n)
+	b = binary.AppendUvarint(b, uint64(len(ps)))
+	for _, p := range ps {
+		b = appendString(b, p.Key)
+		b = appendString(b, p.Value)
+	}
+	return b
+}
+
+/*
+Decodes params encoded by MarshalParams. Returns an error if b is not a
+valid encoding.
+*/
+func UnmarshalParams(b []byte) (httprouter.Params, error) {
+	count, b, err := readUvarint(b)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(b)) {
+		return nil, errShortParams
+	}
+	ps := make(httprouter.Params, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var p httprouter.Param
+		if p.Key, b, err = readString(b); err != nil {
+			return nil, err
+		}
+		if p.Value, b, err = readString(b); err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	if len(b) > 0 {
+		return nil, errors.New("httprouterpersist: trailing data after params encoding")
+	}
+	return ps, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func readUvarint(b []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, nil, errShortParams
+	}
+	return v, b[n:], nil
+}
+
+func readString(b []byte) (string, []byte, error) {
+	n, b, err := readUvarint(b)
+	if err != nil {
+		return "", nil, err
+	}
+	if n > uint64(len(b)) {
+		return "", nil, errShortParams
+	}
+	return string(b[:n]), b[n:], nil
+}
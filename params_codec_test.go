@@ -0,0 +1,36 @@
+package httprouterpersist
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestMarshalParams(t *testing.T) {
+	for _, ps := range []httprouter.Params{
+		{{Key: "id", Value: "42"}, {Key: "filepath", Value: "/docs/2024/report.pdf"}},
+		{{Key: "empty", Value: ""}},
+		{},
+	} {
+		got, err := UnmarshalParams(MarshalParams(ps))
+		if err != nil {
+			t.Fatalf("UnmarshalParams(MarshalParams(%v)): %v", ps, err)
+		}
+		if len(got) != len(ps) || (len(ps) > 0 && !reflect.DeepEqual(got, ps)) {
+			t.Errorf("round trip of %v = %v", ps, got)
+		}
+	}
+}
+
+func TestUnmarshalParamsInvalid(t *testing.T) {
+	b := MarshalParams(httprouter.Params{{Key: "id", Value: "42"}, {Key: "name", Value: "alice"}})
+	for i := 0; i < len(b); i++ {
+		if _, err := UnmarshalParams(b[:i]); err == nil {
+			t.Errorf("UnmarshalParams of %d of %d bytes: expected an error", i, len(b))
+		}
+	}
+	if _, err := UnmarshalParams(append(b, 0)); err == nil {
+		t.Error("UnmarshalParams with trailing data: expected an error")
+	}
+}
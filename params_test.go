@@ -0,0 +1,35 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithParams(t *testing.T) {
+	show := func(w http.ResponseWriter, req *http.Request) {
+		id, err := ParamInt(req, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "%s %d", Param(req, "org"), id)
+	}
+
+	rec := httptest.NewRecorder()
+	show(rec, WithParams(httptest.NewRequest("GET", "/orgs/acme/users/42", nil), map[string]string{"org": "acme", "id": "42"}))
+	if rec.Code != http.StatusOK || rec.Body.String() != "acme 42" {
+		t.Errorf("response = %d %q, want 200 \"acme 42\"", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	show(rec, WithParams(httptest.NewRequest("GET", "/orgs/acme/users/x", nil), map[string]string{"id": "x"}))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("non-numeric id = %d, want 400", rec.Code)
+	}
+
+	if got := Param(httptest.NewRequest("GET", "/", nil), "id"); got != "" {
+		t.Errorf("Param without params = %q, want empty", got)
+	}
+}
@@ -0,0 +1,52 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"time"
+)
+
+/*
+Returns the time spent in each named middleware layer and in the route
+handler, keyed by middleware name and "handler", for a router with Profile
+set. The duration of a layer includes the layers it wraps. Each layer is only
+recorded once it returns, so Timings is best read once the request has been
+served, for example from an AfterResponse hook:
+
+	r.Profile = true
+	r.UseNamed("auth", AuthMiddleware)
+	r.AfterResponse(func(req *http.Request, info router.ResponseInfo) {
+		log.Println(router.Timings(req))
+	})
+*/
+func Timings(r *http.Request) map[string]time.Duration {
+	s := stateFrom(r)
+	if s == nil || s.timings == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	timings := make(map[string]time.Duration, len(s.timings))
+	for name, d := range s.timings {
+		timings[name] = d
+	}
+	return timings
+}
+
+/*
+Wraps h so that the time spent in it is added to the request timings under
+name.
+*/
+func timed(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s := stateFrom(req)
+		if s == nil || s.timings == nil {
+			h.ServeHTTP(w, req)
+			return
+		}
+		start := time.Now()
+		defer func() {
+			s.addTiming(name, time.Since(start))
+		}()
+		h.ServeHTTP(w, req)
+	})
+}
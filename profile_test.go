@@ -0,0 +1,48 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTimings(t *testing.T) {
+	r := New()
+	r.Profile = true
+	r.UseNamed("auth", passThrough)
+	r.UseNamed("logging", passThrough)
+	var timings map[string]time.Duration
+	r.AfterResponse(func(req *http.Request, info ResponseInfo) {
+		timings = Timings(req)
+	})
+	r.WithNamed("audit", passThrough).GET("/", func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(time.Millisecond)
+	})
+
+	serve(r, "GET", "/")
+	if len(timings) != 4 {
+		t.Fatalf("Timings = %v, want auth, logging, audit and handler", timings)
+	}
+	for _, name := range []string{"auth", "logging", "audit", "handler"} {
+		if timings[name] <= 0 {
+			t.Errorf("Timings[%s] = %v, want a positive duration", name, timings[name])
+		}
+	}
+	if timings["auth"] < timings["handler"] {
+		t.Errorf("outer layer took %v, less than the handler's %v", timings["auth"], timings["handler"])
+	}
+}
+
+func TestTimingsWithoutProfile(t *testing.T) {
+	r := New()
+	var timings map[string]time.Duration
+	r.AfterResponse(func(req *http.Request, info ResponseInfo) {
+		timings = Timings(req)
+	})
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) {})
+
+	serve(r, "GET", "/")
+	if timings != nil {
+		t.Errorf("Timings = %v, want nil", timings)
+	}
+}
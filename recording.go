@@ -0,0 +1,83 @@
+package httprouterpersist
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+/*
+The RecordedRequest type is a snapshot of a request taken by
+RecordingMiddleware. Body holds at most MaxRecordedBody bytes; Truncated is
+set when the body was longer.
+*/
+type RecordedRequest struct {
+	Method    string
+	Path      string
+	Query     string
+	Header    http.Header
+	Body      []byte
+	Truncated bool
+}
+
+/*
+The maximum number of body bytes RecordingMiddleware captures per request.
+*/
+const MaxRecordedBody = 64 << 10
+
+/*
+The headers RecordingMiddleware always redacts.
+*/
+var DefaultRedactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+/*
+Returns a middleware that captures the method, path, headers and the first
+MaxRecordedBody bytes of the body of every request and passes them to sink
+before the request is handled, for example to write them to disk and replay
+them later. The handler still reads the complete body. The values of
+DefaultRedactedHeaders and of the headers in redact are replaced with
+"REDACTED".
+
+	r.Use(router.RecordingMiddleware(func(rec router.RecordedRequest) {
+		json.NewEncoder(dumpFile).Encode(rec)
+	}, "X-Api-Key"))
+*/
+func RecordingMiddleware(sink func(RecordedRequest), redact ...string) func(http.Handler) http.Handler {
+	redacted := make(map[string]bool)
+	for _, name := range append(append([]string(nil), DefaultRedactedHeaders...), redact...) {
+		redacted[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rec := RecordedRequest{
+				Method: req.Method,
+				Path:   req.URL.Path,
+				Query:  req.URL.RawQuery,
+				Header: req.Header.Clone(),
+			}
+			for name := range rec.Header {
+				if redacted[name] {
+					rec.Header[name] = []string{"REDACTED"}
+				}
+			}
+
+			if req.Body != nil && req.Body != http.NoBody {
+				body, _ := io.ReadAll(io.LimitReader(req.Body, MaxRecordedBody+1))
+				if len(body) > MaxRecordedBody {
+					rec.Truncated = true
+					rec.Body = body[:MaxRecordedBody]
+				} else {
+					rec.Body = body
+				}
+				req.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+			}
+
+			sink(rec)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package httprouterpersist
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecordingMiddleware(t *testing.T) {
+	var recorded RecordedRequest
+	r := New()
+	r.Use(RecordingMiddleware(func(rec RecordedRequest) { recorded = rec }, "X-Api-Key"))
+	var handlerBody string
+	r.POST("/orders", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		handlerBody = string(b)
+	})
+
+	body := strings.Repeat("x", MaxRecordedBody+10)
+	req := httptest.NewRequest("POST", "/orders?dry_run=1", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Api-Key", "key")
+	req.Header.Set("Content-Type", "text/plain")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if recorded.Method != "POST" || recorded.Path != "/orders" || recorded.Query != "dry_run=1" {
+		t.Errorf("recorded %s %s?%s", recorded.Method, recorded.Path, recorded.Query)
+	}
+	for name, want := range map[string]string{"Authorization": "REDACTED", "X-Api-Key": "REDACTED", "Content-Type": "text/plain"} {
+		if got := recorded.Header.Get(name); got != want {
+			t.Errorf("recorded %s = %q, want %q", name, got, want)
+		}
+	}
+	if len(recorded.Body) != MaxRecordedBody || !recorded.Truncated {
+		t.Errorf("recorded %d body bytes, truncated %v; want %d, true", len(recorded.Body), recorded.Truncated, MaxRecordedBody)
+	}
+	if handlerBody != body {
+		t.Errorf("handler read %d bytes, want %d", len(handlerBody), len(body))
+	}
+	if req.Header.Get("Authorization") != "Bearer secret" {
+		t.Error("redaction changed the request headers")
+	}
+}
@@ -0,0 +1,71 @@
+package httprouterpersist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+/*
+Returns a middleware that assigns every request an ID, readable with
+GetRequestID and sent back in the X-Request-ID response header. An incoming
+X-Request-ID header is kept; otherwise gen is called to create one. When gen
+is nil a random 128-bit hex ID is used.
+
+	r.Use(router.RequestIDMiddleware(nil))
+*/
+func RequestIDMiddleware(gen func() string) func(http.Handler) http.Handler {
+	return RequestIDMiddlewareWithValidation(gen, nil, false)
+}
+
+/*
+Returns a request ID middleware, see RequestIDMiddleware, that only keeps an
+incoming X-Request-ID header if it matches pattern. An ID that does not match
+is replaced by a generated one, or, when rejectInvalid is set, the request is
+rejected with a 400 Bad Request. A nil pattern accepts any ID.
+
+	r.Use(router.RequestIDMiddlewareWithValidation(nil, regexp.MustCompile(`^[0-9a-f]{32}$`), true))
+*/
+func RequestIDMiddlewareWithValidation(gen func() string, pattern *regexp.Regexp, rejectInvalid bool) func(http.Handler) http.Handler {
+	if gen == nil {
+		gen = randomRequestID
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get("X-Request-ID")
+			if id != "" && pattern != nil && !pattern.MatchString(id) {
+				if rejectInvalid {
+					http.Error(w, "invalid X-Request-ID", http.StatusBadRequest)
+					return
+				}
+				id = ""
+			}
+			if id == "" {
+				id = gen()
+			}
+			w.Header().Set("X-Request-ID", id)
+			withState(req, func(req *http.Request, s *requestState) {
+				s.requestID = id
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Returns the ID assigned to r by the request ID middleware, or an empty
+string if there is none.
+*/
+func GetRequestID(r *http.Request) string {
+	if s := stateFrom(r); s != nil {
+		return s.requestID
+	}
+	return ""
+}
+
+func randomRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,51 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestRequestIDMiddlewareWithValidation(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	valid := "0123456789abcdef0123456789abcdef"
+	newRouter := func(reject bool) (*Router, *string) {
+		var seen string
+		r := New()
+		r.Use(RequestIDMiddlewareWithValidation(nil, pattern, reject))
+		r.GET("/", func(w http.ResponseWriter, req *http.Request) {
+			seen = GetRequestID(req)
+		})
+		return r, &seen
+	}
+	get := func(r *Router, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	r, seen := newRouter(false)
+	if rec := get(r, valid); *seen != valid || rec.Header().Get("X-Request-ID") != valid {
+		t.Errorf("valid ID: handler saw %q, header %q", *seen, rec.Header().Get("X-Request-ID"))
+	}
+	for _, id := range []string{"not-a-valid-id\r\n", ""} {
+		rec := get(r, id)
+		if *seen == id || !pattern.MatchString(*seen) || rec.Header().Get("X-Request-ID") != *seen {
+			t.Errorf("ID %q: handler saw %q, header %q, want a generated ID", id, *seen, rec.Header().Get("X-Request-ID"))
+		}
+	}
+
+	r, seen = newRouter(true)
+	*seen = ""
+	if rec := get(r, "bogus"); rec.Code != http.StatusBadRequest || *seen != "" {
+		t.Errorf("reject mode: status %d, handler saw %q; want 400 without reaching the handler", rec.Code, *seen)
+	}
+	if rec := get(r, valid); rec.Code != http.StatusOK || *seen != valid {
+		t.Errorf("reject mode with a valid ID: status %d, handler saw %q", rec.Code, *seen)
+	}
+}
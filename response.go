@@ -0,0 +1,164 @@
+package httprouterpersist
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+Writes v as a JSON response body with the given status code, using the
+encoder set with SetJSONEncoder.
+*/
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	return encodeJSON(w, v)
+}
+
+var encodeJSON = defaultEncodeJSON
+
+func defaultEncodeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+/*
+Replaces the encoder WriteJSON and the helpers built on it use to write JSON
+bodies, for example with a faster third-party implementation. Passing nil
+restores the encoding/json default. It should be called before the router
+starts serving requests.
+
+	router.SetJSONEncoder(func(w io.Writer, v interface{}) error {
+		return jsoniter.NewEncoder(w).Encode(v)
+	})
+*/
+func SetJSONEncoder(fn func(io.Writer, interface{}) error) {
+	if fn == nil {
+		fn = defaultEncodeJSON
+	}
+	encodeJSON = fn
+}
+
+/*
+Writes a 204 No Content response. Any Content-Type, Content-Length or
+Transfer-Encoding header set earlier is removed so that no body or body
+metadata is sent.
+*/
+func WriteNoContent(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Transfer-Encoding")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+Writes items as a JSON response body and adds RFC 5988 Link headers for the
+first, prev, next and last pages, keeping any Link header already set, such
+as the one added by Deprecate. The links are derived from the request url
+by replacing its page and per_page query params, so any other query params
+are kept. There is no prev link on the first page and no next link on the
+last page.
+
+	r.GET("/users", func(w http.ResponseWriter, r *http.Request) {
+		users, total := store.List(page, perPage)
+		router.WritePaginated(w, r, users, page, perPage, total)
+	})
+*/
+func WritePaginated(w http.ResponseWriter, r *http.Request, items interface{}, page, perPage, total int) {
+	if perPage < 1 {
+		perPage = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+	last := (total + perPage - 1) / perPage
+	if last < 1 {
+		last = 1
+	}
+
+	link := func(p int, rel string) string {
+		u := *r.URL
+		values := u.Query()
+		values.Set("page", strconv.Itoa(p))
+		values.Set("per_page", strconv.Itoa(perPage))
+		u.RawQuery = values.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if page > 1 {
+		prev := page - 1
+		if prev > last {
+			prev = last
+		}
+		links = append(links, link(prev, "prev"))
+	}
+	if page < last {
+		links = append(links, link(page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Add("Link", strings.Join(links, ", "))
+
+	WriteJSON(w, http.StatusOK, items)
+}
+
+/*
+Returns a middleware that sets a JSON Content-Type on every response whose
+handler did not set a Content-Type of its own before writing. This prevents
+net/http from sniffing a different content type from the body.
+*/
+func EnforceJSONMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(&jsonWriter{ResponseWriter: w}, req)
+		})
+	}
+}
+
+type jsonWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *jsonWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.Header().Get("Content-Type") == "" && code != http.StatusNoContent && code != http.StatusNotModified {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *jsonWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *jsonWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *jsonWriter) Flush() {
+	w.flush()
+}
+
+func (w *jsonWriter) flush() bool {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return Flush(w.ResponseWriter)
+}
+
+func (w *jsonWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return hijack(w.ResponseWriter)
+}
@@ -0,0 +1,101 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePaginated(t *testing.T) {
+	link := func(page int) string {
+		return "</users?page=" + strconv.Itoa(page) + "&per_page=10&q=shoe>"
+	}
+	for _, tc := range []struct {
+		page int
+		want []string
+	}{
+		{3, []string{link(1) + `; rel="first"`, link(2) + `; rel="prev"`, link(4) + `; rel="next"`, link(5) + `; rel="last"`}},
+		{1, []string{link(1) + `; rel="first"`, link(2) + `; rel="next"`, link(5) + `; rel="last"`}},
+		{5, []string{link(1) + `; rel="first"`, link(4) + `; rel="prev"`, link(5) + `; rel="last"`}},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/users?q=shoe&page=9", nil)
+		WritePaginated(rec, req, []int{1, 2}, tc.page, 10, 45)
+
+		if got, want := rec.Header().Get("Link"), strings.Join(tc.want, ", "); got != want {
+			t.Errorf("page %d: Link =\n%s\nwant\n%s", tc.page, got, want)
+		}
+		if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[1,2]" {
+			t.Errorf("page %d: response = %d %q", tc.page, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestEnforceJSONMiddleware(t *testing.T) {
+	r := New()
+	r.Use(EnforceJSONMiddleware())
+	r.GET("/json", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("<html>not sniffed</html>"))
+	})
+	r.GET("/xml", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte("<user/>"))
+	})
+	r.GET("/empty", func(w http.ResponseWriter, req *http.Request) {
+		WriteNoContent(w)
+	})
+
+	for path, want := range map[string]string{
+		"/json":  "application/json; charset=utf-8",
+		"/xml":   "application/xml",
+		"/empty": "",
+	} {
+		if got := serve(r, "GET", path).Header().Get("Content-Type"); got != want {
+			t.Errorf("GET %s Content-Type = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWriteNoContent(t *testing.T) {
+	r := New()
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "2")
+		WriteNoContent(w)
+	})
+
+	rec := serve(r, "GET", "/")
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("response = %d %q, want 204 with an empty body", rec.Code, rec.Body.String())
+	}
+	for _, h := range []string{"Content-Type", "Content-Length", "Transfer-Encoding"} {
+		if _, ok := rec.Header()[h]; ok {
+			t.Errorf("%s header is set", h)
+		}
+	}
+}
+
+func TestSetJSONEncoder(t *testing.T) {
+	defer SetJSONEncoder(nil)
+	SetJSONEncoder(func(w io.Writer, v interface{}) error {
+		_, err := fmt.Fprintf(w, "custom:%v", v)
+		return err
+	})
+
+	rec := httptest.NewRecorder()
+	WriteJSON(rec, http.StatusOK, 42)
+	if rec.Body.String() != "custom:42" {
+		t.Errorf("body with the custom encoder = %q", rec.Body.String())
+	}
+
+	SetJSONEncoder(nil)
+	rec = httptest.NewRecorder()
+	WriteJSON(rec, http.StatusOK, 42)
+	if rec.Body.String() != "42\n" {
+		t.Errorf("body after reset = %q, want encoding/json output", rec.Body.String())
+	}
+}
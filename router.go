@@ -42,7 +42,11 @@ A trivial example is:
 package httprouterpersist
 
 import (
+	stdcontext "context"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gorilla/context"
 	"github.com/julienschmidt/httprouter"
@@ -53,50 +57,184 @@ The Router type wraps an httprouter.Router type and provides new GET, POST, etc.
 methods that wrap the httprouter calls with a persist params function.
 
 The Persist attribute should be set to a function that can persist or discard
-the httprouter params.
+the httprouter params. SetPersistForMethod overrides it for a single method.
+
+MaxPathParams bounds the number of params a route path may declare; routes
+exceeding it panic at registration, like any other invalid httprouter route.
+It defaults to DefaultMaxPathParams and can be disabled by setting it to zero.
+
+Handlers registered for HEAD cannot send a body: whatever they write is
+discarded and only counted towards the Content-Length header.
+
+ConstraintFailureMode selects how requests failing the param constraints of
+GETValidated and GETRegex routes are answered; by default they get a 404.
+
+If Profile is set, the time spent in every named middleware and in the route
+handler is recorded and can be read with Timings. Profile only applies to
+middleware and routes registered after it is set.
+
+Registering routes, middleware and hooks is safe from multiple goroutines,
+for example from modules that initialize in parallel. Registration should
+still be completed before the router starts serving requests, since serving
+does not take the registration lock.
+
+LogParamFilter selects the route params that are attached to the request
+logger installed by LoggerMiddleware; params whose key it rejects are left
+out. When it is nil every param is attached.
+
+If RejectInvalidEncoding is set, requests whose path contains malformed
+percent-encoding, such as "/users/%zz", get a 400 Bad Request before routing
+instead of falling through to the NotFound handler.
 */
 type Router struct {
 	*httprouter.Router
 	Persist PersistParamsFunc
+
+	MaxPathParams         int
+	RejectInvalidEncoding bool
+	Profile               bool
+	ConstraintFailureMode ConstraintFailureMode
+	LogParamFilter        func(key string) bool
+
+	middleware []namedMiddleware
+	handler    http.Handler
+	routes     []*Route
+	reserved   []*Route
+	after      []func(*http.Request, ResponseInfo)
+	onStatus   map[int]http.HandlerFunc
+	transform  func(int, []byte) (int, []byte)
+	persistFor map[string]PersistParamsFunc
+	mu         sync.RWMutex
+
+	stats   map[string]*RouteStats
+	statsMu sync.Mutex
+
+	longLived   map[uint64]stdcontext.CancelFunc
+	longLivedID uint64
+	longLivedMu sync.Mutex
 }
 
+/*
+The default value of Router.MaxPathParams.
+*/
+const DefaultMaxPathParams = 32
+
 /*
 Returns a new, intialized router that will discard httprouter params.
 */
 func New() *Router {
-	return &Router{httprouter.New(), BlackholePersist}
+	r := &Router{
+		Router:        httprouter.New(),
+		Persist:       BlackholePersist,
+		MaxPathParams: DefaultMaxPathParams,
+	}
+	r.handler = http.HandlerFunc(r.dispatch)
+	r.MethodNotAllowed = http.HandlerFunc(r.methodNotAllowed)
+	return r
+}
+
+func (r *Router) Handle(method, path string, fn http.HandlerFunc) *Route {
+	return r.handle(method, path, nil, nil, fn)
 }
 
-func (r *Router) Handle(method, path string, fn http.HandlerFunc) {
-	r.Router.Handle(method, path, r.wrapHandler(fn))
+func (r *Router) DELETE(path string, fn http.HandlerFunc) *Route {
+	return r.handle("DELETE", path, nil, nil, fn)
 }
 
-func (r *Router) DELETE(path string, fn http.HandlerFunc) {
-	r.Router.DELETE(path, r.wrapHandler(fn))
+func (r *Router) GET(path string, fn http.HandlerFunc) *Route {
+	return r.handle("GET", path, nil, nil, fn)
 }
 
-func (r *Router) GET(path string, fn http.HandlerFunc) {
-	r.Router.GET(path, r.wrapHandler(fn))
+func (r *Router) HEAD(path string, fn http.HandlerFunc) *Route {
+	return r.handle("HEAD", path, nil, nil, fn)
 }
 
-func (r *Router) HEAD(path string, fn http.HandlerFunc) {
-	r.Router.HEAD(path, r.wrapHandler(fn))
+func (r *Router) OPTIONS(path string, fn http.HandlerFunc) *Route {
+	return r.handle("OPTIONS", path, nil, nil, fn)
 }
 
-func (r *Router) OPTIONS(path string, fn http.HandlerFunc) {
-	r.Router.OPTIONS(path, r.wrapHandler(fn))
+func (r *Router) PATCH(path string, fn http.HandlerFunc) *Route {
+	return r.handle("PATCH", path, nil, nil, fn)
 }
 
-func (r *Router) PATCH(path string, fn http.HandlerFunc) {
-	r.Router.PATCH(path, r.wrapHandler(fn))
+func (r *Router) POST(path string, fn http.HandlerFunc) *Route {
+	return r.handle("POST", path, nil, nil, fn)
 }
 
-func (r *Router) POST(path string, fn http.HandlerFunc) {
-	r.Router.POST(path, r.wrapHandler(fn))
+func (r *Router) PUT(path string, fn http.HandlerFunc) *Route {
+	return r.handle("PUT", path, nil, nil, fn)
 }
 
-func (r *Router) PUT(path string, fn http.HandlerFunc) {
-	r.Router.PUT(path, r.wrapHandler(fn))
+/*
+Serves the request through the global middleware registered with Use and
+UseNamed and then dispatches it to the matching route. The time the request
+was received is recorded before the global middleware runs, see StartTime.
+*/
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h := r.handler
+	if h == nil {
+		h = http.HandlerFunc(r.dispatch)
+	}
+	if r.RejectInvalidEncoding && !validPathEncoding(req) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	withState(req, func(req *http.Request, s *requestState) {
+		if s.router == nil {
+			s.router = r
+		}
+		if s.startTime.IsZero() {
+			s.startTime = time.Now()
+		}
+		if r.Profile && s.timings == nil {
+			s.timings = make(map[string]time.Duration)
+		}
+		if len(r.after) == 0 {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		start := s.startTime
+		sw := newStatusWriter(w)
+		defer func() {
+			p := recover()
+			info := ResponseInfo{
+				Status:   sw.status,
+				Bytes:    sw.bytes,
+				Duration: time.Since(start),
+				Panicked: p != nil || s.panicked,
+			}
+			for _, fn := range r.after {
+				fn(req, info)
+			}
+			if p != nil {
+				panic(p)
+			}
+		}()
+		h.ServeHTTP(sw, req)
+	})
+}
+
+/*
+Dispatches req to the matching route. It is the innermost handler of the
+global middleware chain. A response buffered for the response transform is
+dropped if the handler panics.
+*/
+func (r *Router) dispatch(w http.ResponseWriter, req *http.Request) {
+	if r.transform != nil && req.Method != http.MethodHead {
+		tw := &transformWriter{ResponseWriter: w, transform: r.transform}
+		defer func() {
+			if p := recover(); p != nil {
+				panic(p)
+			}
+			tw.finish()
+		}()
+		w = tw
+	}
+	if len(r.onStatus) > 0 {
+		w = &statusInterceptor{ResponseWriter: w, req: req, handlers: r.onStatus}
+	}
+	r.Router.ServeHTTP(w, req)
 }
 
 /*
@@ -115,13 +253,18 @@ func BlackholePersist(r *http.Request, ps httprouter.Params) {
 /*
 A PersistParamsFunc implementation that assigns httprouter params to
 a request context using gorilla context. The params will be attaches as
-key, value pairs on the context.
+key, value pairs on the context. Values set on the request before it reached
+the router are visible to the handler, and the params stay visible on the
+request passed to the router once it returns.
 
 	r.GET("/users/:id", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "User ID: %s", context.Get("id"))
 	})
 */
 func ContextPersist(r *http.Request, ps httprouter.Params) {
+	if s := stateFrom(r); s != nil {
+		s.shareContext(r)
+	}
 	if len(ps) > 0 {
 		for _, param := range ps {
 			context.Set(r, param.Key, param.Value)
@@ -151,9 +294,131 @@ func RequestPersist(r *http.Request, ps httprouter.Params) {
 	return
 }
 
-func (r *Router) wrapHandler(handlerFunc http.HandlerFunc) httprouter.Handle {
+/*
+Returns a PersistParamsFunc that calls inner and reports how long it took to
+sink. The behavior of inner is unchanged, so it can be used to measure any
+persist implementation.
+
+	r.Persist = router.TimedPersist(router.RequestPersist, func(d time.Duration) {
+		persistDuration.Observe(d.Seconds())
+	})
+*/
+func TimedPersist(inner PersistParamsFunc, sink func(time.Duration)) PersistParamsFunc {
+	return func(r *http.Request, ps httprouter.Params) {
+		start := time.Now()
+		inner(r, ps)
+		sink(time.Since(start))
+	}
+}
+
+/*
+Returns a PersistParamsFunc that passes only the params whose key passes
+filter to inner, so that sensitive params are never persisted. The params
+remain available through Param.
+
+	r.Persist = router.KeyFilterPersist(func(key string) bool {
+		return key != "token"
+	}, router.RequestPersist)
+*/
+func KeyFilterPersist(filter func(key string) bool, inner PersistParamsFunc) PersistParamsFunc {
+	return func(r *http.Request, ps httprouter.Params) {
+		kept := make(httprouter.Params, 0, len(ps))
+		for _, p := range ps {
+			if filter(p.Key) {
+				kept = append(kept, p)
+			}
+		}
+		inner(r, kept)
+	}
+}
+
+/*
+Sets the function that persists the params of requests with the given
+method, instead of Persist. Setting it to nil reverts the method to Persist.
+
+	r.Persist = router.ContextPersist
+	r.SetPersistForMethod("GET", router.RequestPersist)
+*/
+func (r *Router) SetPersistForMethod(method string, p PersistParamsFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p == nil {
+		delete(r.persistFor, method)
+		return
+	}
+	if r.persistFor == nil {
+		r.persistFor = make(map[string]PersistParamsFunc)
+	}
+	r.persistFor[method] = p
+}
+
+/*
+Returns the function that persists the params of requests with method.
+*/
+func (r *Router) persistFunc(method string) PersistParamsFunc {
+	if p, ok := r.persistFor[method]; ok {
+		return p
+	}
+	return r.Persist
+}
+
+func (r *Router) handle(method, path string, meta map[string]interface{}, mw []namedMiddleware, fn http.HandlerFunc) *Route {
+	if n := countParams(path); r.MaxPathParams > 0 && n > r.MaxPathParams {
+		panic(fmt.Sprintf("httprouterpersist: path '%s' declares %d params, more than the maximum of %d", path, n, r.MaxPathParams))
+	}
+	if method == http.MethodHead {
+		fn = suppressBody(fn)
+	}
+	rt := &Route{Method: method, Path: path, Meta: meta, middleware: mw, headers: routeHeaders(path, meta)}
+	rt.timeout, rt.hasTimeout = routeTimeout(path, meta)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Router.Handle(method, path, r.wrapHandler(rt, fn))
+	r.routes = append(r.routes, rt)
+	return rt
+}
+
+func (r *Router) wrapHandler(rt *Route, handlerFunc http.HandlerFunc) httprouter.Handle {
+	var h http.Handler = handlerFunc
+	if r.Profile {
+		h = timed("handler", h)
+	}
+	h = r.chain(rt.middleware, h)
 	return func(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		r.Persist(req, ps)
-		handlerFunc(res, req)
+		withState(req, func(req *http.Request, s *requestState) {
+			defer func() {
+				if p := recover(); p != nil {
+					s.panicked = true
+					panic(p)
+				}
+			}()
+			if s.router == nil {
+				s.router = r
+			}
+			s.route = rt
+			s.params = ps
+			deprecation, _ := rt.deprecation.Load().(http.Header)
+			for k, values := range deprecation {
+				for _, v := range values {
+					res.Header().Add(k, v)
+				}
+			}
+			if s.routeHeaders {
+				for k, values := range rt.headers {
+					res.Header()[k] = append([]string(nil), values...)
+				}
+			}
+			if s.logger != nil && len(ps) > 0 {
+				s.logger = s.logger.With(r.paramAttrs(ps)...)
+			}
+			persist := r.persistFunc(req.Method)
+			if d := rt.timeoutFor(s); d > 0 {
+				serveWithTimeout(d, s, persist, ps, h, res, req)
+				return
+			}
+			persist(req, ps)
+			h.ServeHTTP(res, req)
+		})
 	}
 }
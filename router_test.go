@@ -0,0 +1,200 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/context"
+)
+
+func TestContextPersistVisibleToCaller(t *testing.T) {
+	r := New()
+	r.Persist = ContextPersist
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		if got := context.Get(req, "id"); got != "7" {
+			t.Errorf("handler: context id = %v, want 7", got)
+		}
+	})
+
+	req := httptest.NewRequest("GET", "/users/7", nil)
+	defer context.Clear(req)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if got := context.Get(req, "id"); got != "7" {
+		t.Errorf("caller: context id = %v, want 7", got)
+	}
+}
+
+func TestContextPersistSharesCallerValues(t *testing.T) {
+	r := New()
+	r.Persist = ContextPersist
+	var handlerReq *http.Request
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		handlerReq = req
+		if got := context.Get(req, "user"); got != "alice" {
+			t.Errorf("handler: context user = %v, want alice", got)
+		}
+		context.Set(req, "seen", true)
+	})
+
+	req := httptest.NewRequest("GET", "/users/7", nil)
+	defer context.Clear(req)
+	context.Set(req, "user", "alice")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := context.GetAll(req); got["user"] != "alice" || got["id"] != "7" || got["seen"] != true {
+		t.Errorf("caller: context = %v", got)
+	}
+	if got := context.GetAll(handlerReq); got != nil {
+		t.Errorf("handler request still has context values %v", got)
+	}
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	for _, bc := range []struct {
+		name    string
+		persist PersistParamsFunc
+	}{
+		{"Blackhole", BlackholePersist},
+		{"Context", ContextPersist},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			r := New()
+			r.Persist = bc.persist
+			r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+			req := httptest.NewRequest("GET", "/users/42", nil)
+			w := httptest.NewRecorder()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.ServeHTTP(w, req)
+			}
+			context.Clear(req)
+		})
+	}
+}
+
+func TestTimedPersist(t *testing.T) {
+	var d time.Duration
+	r := New()
+	r.Persist = TimedPersist(RequestPersist, func(took time.Duration) { d = took })
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		if got := req.URL.Query().Get("id"); got != "7" {
+			t.Errorf("query id = %q, want 7", got)
+		}
+	})
+
+	if rec := serve(r, "GET", "/users/7"); rec.Code != http.StatusOK {
+		t.Errorf("GET /users/7 = %d, want 200", rec.Code)
+	}
+	if d <= 0 {
+		t.Errorf("sink got %v, want a positive duration", d)
+	}
+}
+
+func TestMaxPathParams(t *testing.T) {
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r := New()
+	r.MaxPathParams = 2
+	r.GET("/orgs/:org/users/:id", ok)
+
+	func() {
+		defer func() {
+			if p := recover(); p == nil || !strings.Contains(p.(string), "declares 3 params") {
+				t.Errorf("recover() = %v, want a panic for 3 params", p)
+			}
+		}()
+		r.GET("/orgs/:org/users/:id/*rest", ok)
+	}()
+
+	if rec := serve(r, "GET", "/orgs/acme/users/42"); rec.Code != http.StatusOK {
+		t.Errorf("GET /orgs/acme/users/42 = %d, want 200", rec.Code)
+	}
+
+	r.MaxPathParams = 0
+	r.GET("/a/:a/b/:b/c/:c", ok)
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	r := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			n := strconv.Itoa(i)
+			r.GET("/modules/"+n+"/:id", func(w http.ResponseWriter, req *http.Request) {
+				w.Write([]byte(n + " " + Param(req, "id")))
+			}).Named("module" + n)
+			r.AfterResponse(func(*http.Request, ResponseInfo) {})
+			r.UseNamed("mw"+n, passThrough)
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(r.MiddlewareFor("GET", "/modules/0/x")); got != 20 {
+		t.Errorf("%d global middleware registered, want 20", got)
+	}
+	for i := 0; i < 20; i++ {
+		n := strconv.Itoa(i)
+		if rec := serve(r, "GET", "/modules/"+n+"/x"); rec.Body.String() != n+" x" {
+			t.Errorf("GET /modules/%s/x = %d %q", n, rec.Code, rec.Body.String())
+		}
+	}
+	if err := r.ValidateNames(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyFilterPersist(t *testing.T) {
+	r := New()
+	r.Persist = KeyFilterPersist(func(key string) bool { return key != "token" }, RequestPersist)
+	r.GET("/share/:id/:token", func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		if q.Get("id") != "42" {
+			t.Errorf("query id = %q, want 42", q.Get("id"))
+		}
+		if _, ok := q["token"]; ok {
+			t.Errorf("filtered key token was persisted: %q", q.Get("token"))
+		}
+		if Param(req, "token") != "s3cret" {
+			t.Errorf("Param(token) = %q, want the matched value", Param(req, "token"))
+		}
+	})
+
+	if rec := serve(r, "GET", "/share/42/s3cret"); rec.Code != http.StatusOK {
+		t.Errorf("GET /share/42/s3cret = %d, want 200", rec.Code)
+	}
+}
+
+func TestSetPersistForMethod(t *testing.T) {
+	r := New()
+	r.Persist = RequestPersist
+	r.SetPersistForMethod("POST", ContextPersist)
+	check := func(wantQuery, wantContext string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if got := req.URL.Query().Get("id"); got != wantQuery {
+				t.Errorf("%s: query id = %q, want %q", req.Method, got, wantQuery)
+			}
+			got, _ := context.Get(req, "id").(string)
+			if got != wantContext {
+				t.Errorf("%s: context id = %q, want %q", req.Method, got, wantContext)
+			}
+		}
+	}
+	r.GET("/users/:id", check("7", ""))
+	r.POST("/users/:id", check("", "7"))
+
+	serve(r, "GET", "/users/7")
+	req := httptest.NewRequest("POST", "/users/7", nil)
+	defer context.Clear(req)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	r.SetPersistForMethod("POST", nil)
+	r.POST("/accounts/:id", check("7", ""))
+	serve(r, "POST", "/accounts/7")
+}
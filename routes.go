@@ -0,0 +1,233 @@
+package httprouterpersist
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+/*
+The Route type records a route registered through the Router. It is returned
+by the registration methods so that the route can be annotated:
+
+	r.GET("/users/:id", ShowUser).Named("user").Describe("Shows a single user")
+
+Returning the route keeps existing calls compiling, since the result can be
+ignored; only code that stores a registration method in a variable of the
+old func type has to be updated.
+*/
+type Route struct {
+	Method      string
+	Path        string
+	Name        string
+	Description string
+	Meta        map[string]interface{}
+
+	middleware  []namedMiddleware
+	deprecation atomic.Value // http.Header set by Deprecate, read while serving
+	headers     http.Header
+	timeout     time.Duration
+	hasTimeout  bool
+}
+
+/*
+Sets the name of the route and returns the route.
+*/
+func (rt *Route) Named(name string) *Route {
+	rt.Name = name
+	return rt
+}
+
+/*
+Sets the human readable description of the route and returns the route.
+*/
+func (rt *Route) Describe(description string) *Route {
+	rt.Description = description
+	return rt
+}
+
+/*
+Registers a route with metadata attached. Metadata is free-form and can be
+read by middleware and handlers through RouteMeta; some keys, such as
+MetaNoCompress and MetaTrailingSlash, are interpreted by the router and the
+package middleware.
+
+	r.HandleMeta("GET", "/archive.zip", map[string]interface{}{
+		router.MetaNoCompress: true,
+	}, ServeArchive)
+*/
+func (r *Router) HandleMeta(method, path string, meta map[string]interface{}, fn http.HandlerFunc) *Route {
+	rt := r.handle(method, path, meta, nil, fn)
+	r.handleTrailingSlash(rt, fn)
+	return rt
+}
+
+/*
+Returns the metadata value stored under key for the route that matched req,
+or nil if there is none.
+*/
+func RouteMeta(req *http.Request, key string) interface{} {
+	if s := stateFrom(req); s != nil && s.route != nil {
+		return s.route.Meta[key]
+	}
+	return nil
+}
+
+/*
+Returns a snapshot of the tracked routes in registration order.
+*/
+func (r *Router) routeList() []*Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*Route(nil), r.routes...)
+}
+
+/*
+Returns the tracked route that httprouter would dispatch method and path to,
+or nil if there is none.
+*/
+func (r *Router) routeFor(method, path string) *Route {
+	r.mu.RLock()
+	handle, ps, _ := r.Router.Lookup(method, path)
+	r.mu.RUnlock()
+	if handle == nil {
+		return nil
+	}
+	for _, rt := range r.routeList() {
+		if rt.Method == method && expandPattern(rt.Path, ps.ByName) == path {
+			return rt
+		}
+	}
+	return nil
+}
+
+/*
+Substitutes the named and catch-all params of pattern with the values
+returned by value. Catch-all values may be given with or without their
+leading slash.
+*/
+func expandPattern(pattern string, value func(string) string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != ':' && c != '*' {
+			b.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(pattern[i:], '/')
+		if end < 0 || c == '*' {
+			end = len(pattern) - i
+		}
+		v := value(pattern[i+1 : i+end])
+		if c == '*' {
+			v = strings.TrimPrefix(v, "/")
+		}
+		b.WriteString(v)
+		i += end - 1
+	}
+	return b.String()
+}
+
+var routesTemplate = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Routes</title></head>
+<body>
+<table>
+<tr><th>Method</th><th>Path</th><th>Name</th><th>Description</th></tr>
+{{- range .}}
+<tr><td>{{.Method}}</td><td>{{.Path}}</td><td>{{.Name}}</td><td>{{.Description}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+/*
+Returns a handler that renders an HTML table of the registered routes with
+their method, path, name and description. All values are HTML escaped.
+
+	r.GET("/debug/routes", r.HTMLRoutesHandler())
+*/
+func (r *Router) HTMLRoutesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		routesTemplate.Execute(w, r.routeList())
+	}
+}
+
+/*
+Checks that every named route can be used for URL generation and that no two
+routes share a name. All problems found are returned together as a single
+error, which makes it suitable for a CI check:
+
+	if err := r.ValidateNames(); err != nil {
+		log.Fatal(err)
+	}
+*/
+func (r *Router) ValidateNames() error {
+	var errs []error
+	seen := make(map[string]*Route)
+	for _, rt := range r.routeList() {
+		if rt.Name == "" {
+			continue
+		}
+		if prev, ok := seen[rt.Name]; ok {
+			errs = append(errs, fmt.Errorf("route name %q is used by %s %s and %s %s",
+				rt.Name, prev.Method, prev.Path, rt.Method, rt.Path))
+			continue
+		}
+		seen[rt.Name] = rt
+		if _, err := patternParams(rt.Path); err != nil {
+			errs = append(errs, fmt.Errorf("route %q: %v", rt.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("httprouterpersist: invalid route names:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+/*
+Returns the names of the params in pattern, or an error if the pattern cannot
+be used to generate a path. The rules are those httprouter applies when the
+route is registered: a param name must be non-empty and end at the next '/',
+and a catch-all param must directly follow a '/' and end the pattern. Named
+params may start mid-segment, as in "/user_:name".
+*/
+func patternParams(pattern string) ([]string, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("path %q must begin with '/'", pattern)
+	}
+	var names []string
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != ':' && c != '*' {
+			continue
+		}
+		end := strings.IndexByte(pattern[i:], '/')
+		if end < 0 {
+			end = len(pattern) - i
+		}
+		if c == '*' && (pattern[i-1] != '/' || i+end != len(pattern)) {
+			return nil, fmt.Errorf("path %q: catch-all param must follow a '/' at the end", pattern)
+		}
+		name := pattern[i+1 : i+end]
+		if name == "" || strings.ContainsAny(name, ":*") {
+			return nil, fmt.Errorf("path %q: invalid param name %q", pattern, name)
+		}
+		names = append(names, name)
+		i += end - 1
+	}
+	return names, nil
+}
+
+/*
+Returns the number of named and catch-all params declared by path.
+*/
+func countParams(path string) int {
+	return strings.Count(path, ":") + strings.Count(path, "*")
+}
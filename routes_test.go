@@ -0,0 +1,56 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHTMLRoutesHandler(t *testing.T) {
+	r := New()
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.GET("/users/:id", ok).Named("user").Describe("Shows a single user")
+	r.POST("/users", ok).Describe(`<script>alert("x")</script>`)
+	r.GET("/routes", r.HTMLRoutesHandler())
+
+	rec := serve(r, "GET", "/routes")
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"<td>GET</td><td>/users/:id</td><td>user</td><td>Shows a single user</td>",
+		"<td>POST</td><td>/users</td>",
+		"<td>/routes</td>",
+		"&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "<script>") {
+		t.Errorf("description was not escaped:\n%s", body)
+	}
+}
+
+func TestValidateNames(t *testing.T) {
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+
+	r := New()
+	r.GET("/users/:id", ok).Named("user")
+	r.GET("/users", ok).Named("users")
+	r.GET("/user_:name", ok).Named("profile")
+	r.POST("/users", ok)
+	if err := r.ValidateNames(); err != nil {
+		t.Errorf("ValidateNames() = %v, want nil", err)
+	}
+
+	r.GET("/accounts/:id", ok).Named("user")
+	err := r.ValidateNames()
+	if err == nil {
+		t.Fatal("ValidateNames() = nil, want an error for the duplicate name")
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"user"`) || !strings.Contains(msg, "/users/:id") || !strings.Contains(msg, "/accounts/:id") {
+		t.Errorf("error does not name the duplicate routes: %v", err)
+	}
+}
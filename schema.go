@@ -0,0 +1,286 @@
+package httprouterpersist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+The schema type is a compiled JSON Schema. Only the subset of keywords needed
+to validate request bodies is supported: type, enum, properties, required,
+additionalProperties (as a boolean), items, minLength, maxLength, pattern,
+minimum and maximum. Unknown keywords are ignored.
+*/
+type schema struct {
+	types                []string
+	enum                 []interface{}
+	properties           map[string]*schema
+	required             []string
+	additionalProperties *bool
+	items                *schema
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     *float64
+}
+
+/*
+The largest request body, in bytes, that a POSTSchema route reads. Larger
+bodies are rejected with 413 Request Entity Too Large.
+*/
+const MaxSchemaBody = 1 << 20
+
+/*
+Registers a POST route whose JSON request body must be valid against the
+JSON Schema document schemaJSON. The schema is compiled at registration,
+which panics if it is invalid. Requests with an invalid body get a 422
+Unprocessable Entity with a JSON body listing the errors before fn runs;
+fn can read the body as usual. Bodies larger than MaxSchemaBody get a 413.
+See the schema type for the supported keywords.
+
+	r.POSTSchema("/users", []byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {"name": {"type": "string", "minLength": 1}}
+	}`), CreateUser)
+*/
+func (r *Router) POSTSchema(path string, schemaJSON []byte, fn http.HandlerFunc) *Route {
+	var raw interface{}
+	if err := json.Unmarshal(schemaJSON, &raw); err != nil {
+		panic(fmt.Sprintf("httprouterpersist: invalid schema for path '%s': %v", path, err))
+	}
+	s, err := compileSchema(raw)
+	if err != nil {
+		panic(fmt.Sprintf("httprouterpersist: invalid schema for path '%s': %v", path, err))
+	}
+
+	return r.POST(path, func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, MaxSchemaBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		var doc interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			WriteJSON(w, http.StatusUnprocessableEntity, map[string][]string{"errors": {"invalid JSON: " + err.Error()}})
+			return
+		}
+		if errs := s.validate("", doc, nil); len(errs) > 0 {
+			WriteJSON(w, http.StatusUnprocessableEntity, map[string][]string{"errors": errs})
+			return
+		}
+		fn(w, req)
+	})
+}
+
+func compileSchema(raw interface{}) (*schema, error) {
+	if b, ok := raw.(bool); ok {
+		if b {
+			return &schema{}, nil
+		}
+		return &schema{enum: []interface{}{}}, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema must be an object, got %T", raw)
+	}
+
+	s := &schema{}
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("type must be a string or an array of strings")
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("type must be a string or an array of strings")
+	}
+	if enum, ok := m["enum"].([]interface{}); ok {
+		s.enum = enum
+	}
+	if props, ok := m["properties"].(map[string]interface{}); ok {
+		s.properties = make(map[string]*schema, len(props))
+		for name, p := range props {
+			ps, err := compileSchema(p)
+			if err != nil {
+				return nil, fmt.Errorf("properties.%s: %v", name, err)
+			}
+			s.properties[name] = ps
+		}
+	}
+	if required, ok := m["required"].([]interface{}); ok {
+		for _, v := range required {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("required must be an array of strings")
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	if additional, ok := m["additionalProperties"].(bool); ok {
+		s.additionalProperties = &additional
+	}
+	if items, ok := m["items"]; ok {
+		is, err := compileSchema(items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %v", err)
+		}
+		s.items = is
+	}
+	s.minLength = schemaInt(m, "minLength")
+	s.maxLength = schemaInt(m, "maxLength")
+	s.minimum = schemaFloat(m, "minimum")
+	s.maximum = schemaFloat(m, "maximum")
+	if pattern, ok := m["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern: %v", err)
+		}
+		s.pattern = re
+	}
+	return s, nil
+}
+
+func schemaInt(m map[string]interface{}, key string) *int {
+	if f, ok := m[key].(float64); ok {
+		n := int(f)
+		return &n
+	}
+	return nil
+}
+
+func schemaFloat(m map[string]interface{}, key string) *float64 {
+	if f, ok := m[key].(float64); ok {
+		return &f
+	}
+	return nil
+}
+
+/*
+Appends the validation errors of v, found at the JSON pointer at, to errs.
+*/
+func (s *schema) validate(at string, v interface{}, errs []string) []string {
+	where := at
+	if where == "" {
+		where = "/"
+	}
+
+	if len(s.types) > 0 && !s.hasType(v) {
+		return append(errs, fmt.Sprintf("%s: expected %s, got %s", where, strings.Join(s.types, " or "), jsonType(v)))
+	}
+	if s.enum != nil && !inEnum(s.enum, v) {
+		errs = append(errs, fmt.Sprintf("%s: value is not one of the allowed values", where))
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.minLength != nil && n < *s.minLength {
+			errs = append(errs, fmt.Sprintf("%s: length must be at least %d", where, *s.minLength))
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			errs = append(errs, fmt.Sprintf("%s: length must be at most %d", where, *s.maxLength))
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			errs = append(errs, fmt.Sprintf("%s: must match pattern %q", where, s.pattern.String()))
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.minimum != nil && f < *s.minimum {
+			errs = append(errs, fmt.Sprintf("%s: must be at least %v", where, *s.minimum))
+		}
+		if s.maximum != nil && f > *s.maximum {
+			errs = append(errs, fmt.Sprintf("%s: must be at most %v", where, *s.maximum))
+		}
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", where, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ps, ok := s.properties[name]
+			switch {
+			case ok:
+				errs = ps.validate(at+"/"+name, v[name], errs)
+			case s.additionalProperties != nil && !*s.additionalProperties:
+				errs = append(errs, fmt.Sprintf("%s: unexpected property %q", where, name))
+			}
+		}
+	case []interface{}:
+		if s.items != nil {
+			for i, item := range v {
+				errs = s.items.validate(fmt.Sprintf("%s/%d", at, i), item, errs)
+			}
+		}
+	}
+	return errs
+}
+
+func (s *schema) hasType(v interface{}) bool {
+	t := jsonType(v)
+	for _, want := range s.types {
+		if want == t || want == "number" && t == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	b, _ := json.Marshal(v)
+	for _, e := range enum {
+		eb, _ := json.Marshal(e)
+		if bytes.Equal(b, eb) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package httprouterpersist
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var userSchema = []byte(`{
+	"type": "object",
+	"required": ["name"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"tags": {"type": "array", "items": {"enum": ["a", "b"]}}
+	}
+}`)
+
+func post(r http.Handler, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("POST", path, strings.NewReader(body)))
+	return rec
+}
+
+func TestPOSTSchema(t *testing.T) {
+	r := New()
+	var got string
+	r.POSTSchema("/users", userSchema, func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		got = string(b)
+	})
+	r.POST("/other", func(w http.ResponseWriter, req *http.Request) {})
+
+	valid := `{"name":"ada","age":36,"tags":["a"]}`
+	if rec := post(r, "/users", valid); rec.Code != http.StatusOK || got != valid {
+		t.Errorf("valid body = %d, handler read %q", rec.Code, got)
+	}
+
+	got = ""
+	rec := post(r, "/users", `{"age":-1,"tags":["c"],"extra":true}`)
+	var res struct{ Errors []string }
+	json.Unmarshal(rec.Body.Bytes(), &res)
+	if rec.Code != http.StatusUnprocessableEntity || len(res.Errors) != 4 || got != "" {
+		t.Errorf("invalid body = %d %v, handler ran: %v", rec.Code, res.Errors, got != "")
+	}
+
+	if rec := post(r, "/users", `{`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("malformed body = %d", rec.Code)
+	}
+	if rec := post(r, "/other", `{"age":"x"}`); rec.Code != http.StatusOK {
+		t.Errorf("other route = %d", rec.Code)
+	}
+}
+
+func TestPOSTSchemaBodyLimit(t *testing.T) {
+	r := New()
+	r.POSTSchema("/users", userSchema, func(w http.ResponseWriter, req *http.Request) {})
+	body := `{"name":"` + strings.Repeat("x", MaxSchemaBody) + `"}`
+	if rec := post(r, "/users", body); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body = %d, want 413", rec.Code)
+	}
+}
+
+func TestPOSTSchemaInvalidSchema(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid schema did not panic")
+		}
+	}()
+	New().POSTSchema("/x", []byte(`{"type": 1}`), func(http.ResponseWriter, *http.Request) {})
+}
@@ -0,0 +1,102 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"strings"
+)
+
+/*
+Returns a middleware that rejects requests carrying signs of header smuggling
+or header injection with a 400 Bad Request. The following checks run, in
+order:
+
+  - Content-Length appears more than once.
+  - Content-Length is combined with Transfer-Encoding.
+  - A header name is not a valid RFC 7230 token.
+  - A header value contains CR, LF, NUL or another control character other
+    than horizontal tab.
+
+net/http already rejects some of these on the wire; the middleware also
+protects handlers reached through other servers, proxies or tests.
+*/
+func HeaderSanityMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !saneHeaders(req) {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func saneHeaders(req *http.Request) bool {
+	contentLength := req.Header.Values("Content-Length")
+	if len(contentLength) > 1 || strings.Contains(strings.Join(contentLength, ""), ",") {
+		return false
+	}
+	chunked := len(req.TransferEncoding) > 0 || len(req.Header.Values("Transfer-Encoding")) > 0
+	if len(contentLength) > 0 && chunked {
+		return false
+	}
+
+	for name, values := range req.Header {
+		if !isToken(name) {
+			return false
+		}
+		for _, v := range values {
+			for i := 0; i < len(v); i++ {
+				if c := v[i]; (c < ' ' && c != '\t') || c == 0x7f {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+/*
+Reports whether s is a non-empty RFC 7230 token.
+*/
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Reports whether the raw request path only contains well-formed percent
+escapes. The raw path is taken from the request line when available, since
+req.URL.Path has already been decoded.
+*/
+func validPathEncoding(req *http.Request) bool {
+	raw := req.URL.RawPath
+	if req.RequestURI != "" {
+		raw, _, _ = strings.Cut(req.RequestURI, "?")
+	}
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '%' {
+			continue
+		}
+		if i+2 >= len(raw) || !isHex(raw[i+1]) || !isHex(raw[i+2]) {
+			return false
+		}
+		i += 2
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
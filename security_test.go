@@ -0,0 +1,75 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderSanityMiddleware(t *testing.T) {
+	r := New()
+	r.Use(HeaderSanityMiddleware())
+	r.POST("/upload", func(w http.ResponseWriter, req *http.Request) {})
+
+	for name, header := range map[string]http.Header{
+		"clean":                nil,
+		"duplicate length":     {"Content-Length": {"5", "6"}},
+		"list length":          {"Content-Length": {"5, 6"}},
+		"length and chunked":   {"Content-Length": {"5"}, "Transfer-Encoding": {"chunked"}},
+		"CRLF in value":        {"X-Note": {"a\r\nX-Admin: true"}},
+		"LF in value":          {"X-Note": {"a\nb"}},
+		"invalid name":         {"X Note": {"a"}},
+		"tab in value is fine": {"X-Note": {"a\tb"}},
+	} {
+		req := httptest.NewRequest("POST", "/upload", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		want := http.StatusBadRequest
+		if name == "clean" || name == "tab in value is fine" {
+			want = http.StatusOK
+		}
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, want)
+		}
+	}
+}
+
+func TestRejectInvalidEncoding(t *testing.T) {
+	r := New()
+	r.RejectInvalidEncoding = true
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(Param(req, "id")))
+	})
+
+	for uri, want := range map[string]int{
+		"/users/%zz":     http.StatusBadRequest,
+		"/users/%4":      http.StatusBadRequest,
+		"/users/a%20b":   http.StatusOK,
+		"/users/42?q=%z": http.StatusOK,
+	} {
+		req := httptest.NewRequest("GET", "/users/placeholder", nil)
+		req.RequestURI = uri
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", uri, rec.Code, want)
+		}
+	}
+
+	if rec := serve(r, "GET", "/users/a%20b"); rec.Body.String() != "a b" {
+		t.Errorf("GET /users/a%%20b body = %q, want \"a b\"", rec.Body.String())
+	}
+
+	r.RejectInvalidEncoding = false
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.RequestURI = "/missing/%zz"
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without RejectInvalidEncoding = %d, want 404", rec.Code)
+	}
+}
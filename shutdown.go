@@ -0,0 +1,63 @@
+package httprouterpersist
+
+import (
+	"context"
+	"net/http"
+)
+
+/*
+Registers the cancel function of a long-lived request, such as a WebSocket
+or server-sent events stream, so that Shutdown can end it. The returned
+release function must be called when the request finishes on its own:
+
+	r.GET("/events", func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		defer r.TrackLongLived(cancel)()
+		streamEvents(ctx, w)
+	})
+*/
+func (r *Router) TrackLongLived(cancel context.CancelFunc) (release func()) {
+	r.longLivedMu.Lock()
+	defer r.longLivedMu.Unlock()
+	if r.longLived == nil {
+		r.longLived = make(map[uint64]context.CancelFunc)
+	}
+	id := r.longLivedID
+	r.longLivedID++
+	r.longLived[id] = cancel
+
+	return func() {
+		r.longLivedMu.Lock()
+		defer r.longLivedMu.Unlock()
+		delete(r.longLived, id)
+	}
+}
+
+/*
+Gracefully shuts down srv, which serves the router. Shutdown waits for
+in-flight requests until ctx is done, as http.Server.Shutdown does. If ctx
+expires first, the requests registered with TrackLongLived are cancelled and
+the remaining connections are closed, so long-lived requests cannot hold up
+the shutdown past the grace period. In that case the context error is
+returned.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r.Shutdown(ctx, srv)
+*/
+func (r *Router) Shutdown(ctx context.Context, srv *http.Server) error {
+	err := srv.Shutdown(ctx)
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	r.longLivedMu.Lock()
+	for id, cancel := range r.longLived {
+		cancel()
+		delete(r.longLived, id)
+	}
+	r.longLivedMu.Unlock()
+
+	srv.Close()
+	return err
+}
@@ -0,0 +1,56 @@
+package httprouterpersist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShutdownCancelsLongLived(t *testing.T) {
+	r := New()
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	r.GET("/events", func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		defer r.TrackLongLived(cancel)()
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	go func() {
+		if resp, err := http.Get(srv.URL + "/events"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := r.Shutdown(ctx, srv.Config); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("long-lived handler was not cancelled")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Shutdown took %v", d)
+	}
+}
+
+func TestShutdownIdle(t *testing.T) {
+	r := New()
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	if err := r.Shutdown(context.Background(), srv.Config); err != nil {
+		t.Errorf("Shutdown = %v, want nil", err)
+	}
+}
@@ -0,0 +1,51 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"time"
+)
+
+/*
+Returns a middleware that records the time each request was received, so
+that handlers and middleware share a single timestamp. Handlers read it with
+StartTime; the logging, metrics and AfterResponse durations of the package
+are measured from it. The router already records it before its global
+middleware runs, so the middleware is only needed to record it earlier, for
+handlers that wrap the router:
+
+	handler := router.StartTimeMiddleware()(authenticate(r))
+*/
+func StartTimeMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			withState(req, func(req *http.Request, s *requestState) {
+				if s.startTime.IsZero() {
+					s.startTime = time.Now()
+				}
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Returns the time r was received as recorded by the router or by
+StartTimeMiddleware, or the zero time if it was not recorded.
+*/
+func StartTime(r *http.Request) time.Time {
+	if s := stateFrom(r); s != nil {
+		return s.startTime
+	}
+	return time.Time{}
+}
+
+/*
+Returns the time measurements of r should start from: its StartTime if
+recorded, or else the current time.
+*/
+func startTime(r *http.Request) time.Time {
+	if t := StartTime(r); !t.IsZero() {
+		return t
+	}
+	return time.Now()
+}
@@ -0,0 +1,56 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStartTime(t *testing.T) {
+	r := New()
+	r.Use(StartTimeMiddleware())
+	var start time.Time
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) {
+		start = StartTime(req)
+		if now := time.Now(); !start.Before(now) {
+			t.Errorf("StartTime %v is not before %v", start, now)
+		}
+	})
+
+	before := time.Now()
+	serve(r, "GET", "/")
+	if start.IsZero() || start.Before(before) {
+		t.Errorf("StartTime = %v, want a time after %v", start, before)
+	}
+	if got := StartTime(httptest.NewRequest("GET", "/", nil)); !got.IsZero() {
+		t.Errorf("StartTime without the middleware = %v, want the zero time", got)
+	}
+}
+
+func TestStartTimeBeforeMiddleware(t *testing.T) {
+	r := New()
+	var first time.Time
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			first = StartTime(req)
+			time.Sleep(5 * time.Millisecond)
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.GET("/", func(w http.ResponseWriter, req *http.Request) {})
+	var info ResponseInfo
+	var since time.Duration
+	r.AfterResponse(func(req *http.Request, i ResponseInfo) {
+		since = time.Since(StartTime(req))
+		info = i
+	})
+
+	serve(r, "GET", "/")
+	if first.IsZero() {
+		t.Error("StartTime was not recorded before the global middleware")
+	}
+	if info.Duration < 5*time.Millisecond || info.Duration > since {
+		t.Errorf("Duration = %v, want at least the 5ms middleware and at most %v since StartTime", info.Duration, since)
+	}
+}
@@ -0,0 +1,184 @@
+package httprouterpersist
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+type stateKeyType struct{}
+
+var stateKey = stateKeyType{}
+
+/*
+The requestState type holds the per-request information the router and the
+package middleware share. A single state is attached to the request context
+the first time it is needed and is then filled in as the request moves through
+the middleware and the router. The state is itself the context node that
+carries it, so attaching it costs a single allocation besides the request
+copy.
+*/
+type requestState struct {
+	context.Context
+
+	router  *Router
+	route   *Route
+	params  httprouter.Params
+	allowed []string
+
+	pagination     *Pagination
+	timings        map[string]time.Duration
+	credentials    *Credentials
+	flags          map[string]bool
+	mountPrefix    string
+	originalPath   string
+	originalMethod string
+	startTime      time.Time
+	defaultTimeout time.Duration
+	requestID      string
+	logger         *slog.Logger
+
+	orig    *http.Request
+	gorilla *http.Request
+
+	// mu guards timings, which the handler goroutine of a route served under
+	// a timeout may still write once the request was abandoned.
+	mu sync.Mutex
+
+	panicked     bool
+	timeouts     bool
+	routeHeaders bool
+	abandoned    atomic.Bool
+}
+
+/*
+Returns the state for the stateKey key and defers any other key to the parent
+context.
+*/
+func (s *requestState) Value(key any) any {
+	if key == stateKey {
+		return s
+	}
+	return s.Context.Value(key)
+}
+
+/*
+Returns a copy of req carrying s.
+*/
+func (s *requestState) attach(req *http.Request) *http.Request {
+	s.Context = req.Context()
+	return req.WithContext(s)
+}
+
+/*
+Returns the request state attached to req, or nil if there is none.
+*/
+func stateFrom(req *http.Request) *requestState {
+	s, _ := req.Context().Value(stateKey).(*requestState)
+	return s
+}
+
+/*
+Returns the template of the matched route, or an empty string if the request
+has not matched a route.
+*/
+func (s *requestState) routePath() string {
+	if s.route == nil {
+		return ""
+	}
+	return s.route.Path
+}
+
+/*
+Calls fn with a request that carries a request state. If req has no state yet
+a new one is attached, which happens once per request; nested calls share
+it. Gorilla context is only touched when ContextPersist stored values during
+fn, see shareContext: those values are moved back to req once fn returns.
+*/
+func withState(req *http.Request, fn func(*http.Request, *requestState)) {
+	if s := stateFrom(req); s != nil {
+		fn(req, s)
+		return
+	}
+
+	s := &requestState{orig: req}
+	defer s.restoreContext()
+	fn(s.attach(req), s)
+}
+
+/*
+Prepares r to hold the gorilla context values of the request. Since gorilla
+context is keyed by the request pointer, the values of the request the state
+was attached to, or of the request values were last stored on, are moved to
+r, so that handlers see them on the request they are given.
+*/
+func (s *requestState) shareContext(r *http.Request) {
+	if s.abandoned.Load() || r == s.orig || r == s.gorilla {
+		return
+	}
+	from := s.orig
+	if s.gorilla != nil {
+		from = s.gorilla
+	}
+	for k, v := range gcontext.GetAll(from) {
+		gcontext.Set(r, k, v)
+	}
+	if from != s.orig {
+		gcontext.Clear(from)
+	}
+	s.gorilla = r
+}
+
+/*
+Moves the gorilla context values stored by ContextPersist back to the request
+the state was attached to, so that they stay visible to code holding it, as
+they were when the params were persisted on that request itself. Nothing is
+moved once the request was abandoned by a timeout, see serveWithTimeout.
+*/
+func (s *requestState) restoreContext() {
+	if !s.abandoned.Load() && s.gorilla != nil {
+		moveContext(s.gorilla, s.orig)
+	}
+}
+
+/*
+Marks the request as abandoned by a timeout. From then on the handler
+goroutine that still runs no longer records timings or shares gorilla
+context values.
+*/
+func (s *requestState) abandon() {
+	s.mu.Lock()
+	s.abandoned.Store(true)
+	s.mu.Unlock()
+}
+
+/*
+Adds d to the timings recorded under name, unless the request was
+abandoned.
+*/
+func (s *requestState) addTiming(name string, d time.Duration) {
+	s.mu.Lock()
+	if !s.abandoned.Load() {
+		s.timings[name] += d
+	}
+	s.mu.Unlock()
+}
+
+/*
+Replaces the gorilla context values of to with those of from and clears
+from.
+*/
+func moveContext(from, to *http.Request) {
+	values := gcontext.GetAll(from)
+	gcontext.Clear(from)
+	gcontext.Clear(to)
+	for k, v := range values {
+		gcontext.Set(to, k, v)
+	}
+}
@@ -0,0 +1,163 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+/*
+Paths longer than maxSuggestPathLen are not compared, which bounds the cost
+of a single distance computation, and at most maxSuggestCandidates paths are
+compared for a single request.
+*/
+const (
+	maxSuggestPathLen    = 128
+	maxSuggestCandidates = 256
+)
+
+/*
+Returns a NotFound handler that answers with a 404 whose body suggests the
+registered path closest to the requested one, if there is one within a few
+edits:
+
+	r.NotFound = r.SuggestNotFound()
+
+	GET /usrs
+	404 page not found
+
+	Did you mean /users?
+
+Paths are compared with the Levenshtein distance. Route paths are indexed by
+length, so only those whose length is within the allowed distance of the
+requested path are looked at, closest lengths first, and the search gives up
+after maxSuggestCandidates comparisons; the computation also stops early once
+the distance is exceeded. The cost of a 404 is thus bounded regardless of the
+size of the route table. Route patterns are compared as written, params
+included.
+*/
+func (r *Router) SuggestNotFound() http.Handler {
+	idx := &pathIndex{seen: make(map[string]bool), byLen: make(map[int][]string)}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body := "404 page not found\n"
+		if s := idx.suggest(r, req.URL.Path); s != "" {
+			body += fmt.Sprintf("\nDid you mean %s?\n", s)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, body)
+	})
+}
+
+/*
+The pathIndex type indexes the distinct tracked route paths of a router by
+length. Routes are only ever appended, so the index is extended with the
+routes registered since it was last used.
+*/
+type pathIndex struct {
+	mu      sync.Mutex
+	indexed int
+	seen    map[string]bool
+	byLen   map[int][]string
+}
+
+/*
+Adds the routes of r registered since the last call to the index. The caller
+must hold idx.mu.
+*/
+func (idx *pathIndex) update(r *Router) {
+	r.mu.RLock()
+	routes := r.routes[idx.indexed:]
+	idx.indexed = len(r.routes)
+	r.mu.RUnlock()
+	for _, rt := range routes {
+		if len(rt.Path) > maxSuggestPathLen || idx.seen[rt.Path] {
+			continue
+		}
+		idx.seen[rt.Path] = true
+		idx.byLen[len(rt.Path)] = append(idx.byLen[len(rt.Path)], rt.Path)
+	}
+}
+
+/*
+Returns the tracked route path of r closest to path, or an empty string if
+none is within a third of its length, at most three edits.
+*/
+func (idx *pathIndex) suggest(r *Router, path string) string {
+	if len(path) > maxSuggestPathLen {
+		return ""
+	}
+	max := len(path) / 3
+	if max > 3 {
+		max = 3
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.update(r)
+
+	best, bestDist := "", max+1
+	compared := 0
+	for diff := 0; diff < bestDist; diff++ {
+		lengths := []int{len(path) - diff, len(path) + diff}
+		if diff == 0 {
+			lengths = lengths[:1]
+		}
+		for _, n := range lengths {
+			for _, p := range idx.byLen[n] {
+				if p == path {
+					continue
+				}
+				if compared == maxSuggestCandidates {
+					return best
+				}
+				compared++
+				if d := levenshtein(path, p, bestDist-1); d < bestDist {
+					best, bestDist = p, d
+				}
+			}
+		}
+	}
+	return best
+}
+
+/*
+Returns the edit distance between a and b, or max+1 once it is known to
+exceed max.
+*/
+func levenshtein(a, b string, max int) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(n int, rest ...int) int {
+	for _, m := range rest {
+		if m < n {
+			n = m
+		}
+	}
+	return n
+}
@@ -0,0 +1,44 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSuggestNotFound(t *testing.T) {
+	r := New()
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.GET("/users", ok)
+	r.GET("/orders/:id", ok)
+	r.NotFound = r.SuggestNotFound()
+
+	rec := serve(r, "GET", "/usrs")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "Did you mean /users?") {
+		t.Errorf("GET /usrs = %d %q, want a suggestion for /users", rec.Code, rec.Body.String())
+	}
+	for _, path := range []string{"/completely/unrelated", "/x", "/" + strings.Repeat("a", 200)} {
+		rec := serve(r, "GET", path)
+		if rec.Code != http.StatusNotFound || strings.Contains(rec.Body.String(), "Did you mean") {
+			t.Errorf("GET %s = %d %q, want a 404 without a suggestion", path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestSuggestNotFoundBounded(t *testing.T) {
+	r := New()
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.NotFound = r.SuggestNotFound()
+	r.GET("/users", ok)
+	if rec := serve(r, "GET", "/usrs"); !strings.Contains(rec.Body.String(), "Did you mean /users?") {
+		t.Errorf("GET /usrs = %q, want a suggestion for /users", rec.Body.String())
+	}
+
+	for i := 0; i < maxSuggestCandidates; i++ {
+		r.GET(fmt.Sprintf("/%04d", i), ok)
+	}
+	if rec := serve(r, "GET", "/usrs"); strings.Contains(rec.Body.String(), "Did you mean") {
+		t.Errorf("GET /usrs = %q, want no suggestion once %d closer-length paths were compared", rec.Body.String(), maxSuggestCandidates)
+	}
+}
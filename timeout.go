@@ -0,0 +1,110 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+/*
+The metadata key that sets the timeout of a route for TimeoutMiddleware. The
+value is a duration string, as accepted by time.ParseDuration, or a
+time.Duration; "0s" exempts the route from the default timeout. It is parsed
+when the route is registered, which panics if it is invalid.
+
+	r.GETMeta("/reports/export", map[string]interface{}{
+		router.MetaTimeout: "30s",
+	}, ExportReports)
+*/
+const MetaTimeout = "timeout"
+
+/*
+Returns a middleware that limits the time the handler of every matched route
+may take to the MetaTimeout of the route, or def for routes without one.
+Handlers that run out of time are answered with a 503 Service Unavailable
+and their request context is cancelled, see http.TimeoutHandler. A timeout of
+zero disables the limit. The middleware only enables the limit; it is applied
+by the router once the route is matched, so install it with Use:
+
+	r.Use(router.TimeoutMiddleware(5 * time.Second))
+
+http.TimeoutHandler buffers the response, so handlers running with a timeout
+cannot flush or hijack the connection. Give streaming and WebSocket routes a
+MetaTimeout of "0s".
+*/
+func TimeoutMiddleware(def time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			withState(req, func(req *http.Request, s *requestState) {
+				s.timeouts = true
+				s.defaultTimeout = def
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+/*
+Returns the timeout that applies to rt for a request with state s, or zero
+if there is none.
+*/
+func (rt *Route) timeoutFor(s *requestState) time.Duration {
+	switch {
+	case !s.timeouts:
+		return 0
+	case rt.hasTimeout:
+		return rt.timeout
+	}
+	return s.defaultTimeout
+}
+
+/*
+Serves req with h under http.TimeoutHandler, persisting ps on the request
+the timeout handler passes on. When the timeout expires first the handler
+keeps running in its own goroutine; the request state s is then marked as
+abandoned, so that the handler no longer touches it, and the gorilla context
+values stored on the inner request are cleared once the handler returns.
+*/
+func serveWithTimeout(d time.Duration, s *requestState, persist PersistParamsFunc, ps httprouter.Params, h http.Handler, w http.ResponseWriter, req *http.Request) {
+	const (
+		running int32 = iota
+		done
+		abandoned
+	)
+	var state atomic.Int32
+	http.TimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, inner *http.Request) {
+		defer func() {
+			if !state.CompareAndSwap(running, done) {
+				gcontext.Clear(inner)
+			}
+		}()
+		persist(inner, ps)
+		h.ServeHTTP(w, inner)
+	}), d, "").ServeHTTP(w, req)
+	if state.CompareAndSwap(running, abandoned) {
+		s.abandon()
+	}
+}
+
+/*
+Returns the MetaTimeout of a route registered for path with meta, and
+whether it has one.
+*/
+func routeTimeout(path string, meta map[string]interface{}) (time.Duration, bool) {
+	switch v := meta[MetaTimeout].(type) {
+	case nil:
+		return 0, false
+	case time.Duration:
+		return v, true
+	case string:
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			return d, true
+		}
+	}
+	panic(fmt.Sprintf("httprouterpersist: invalid timeout %v for path '%s'", meta[MetaTimeout], path))
+}
@@ -0,0 +1,104 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	r := New()
+	r.Use(TimeoutMiddleware(20 * time.Millisecond))
+	slow := func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			w.Write([]byte(Param(req, "id")))
+		case <-req.Context().Done():
+		}
+	}
+	r.GET("/default/:id", slow)
+	r.GETMeta("/long/:id", map[string]interface{}{MetaTimeout: "1s"}, slow)
+	r.GETMeta("/none/:id", map[string]interface{}{MetaTimeout: time.Duration(0)}, slow)
+
+	if rec := serve(r, "GET", "/default/1"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /default/1 = %d, want 503", rec.Code)
+	}
+	for _, path := range []string{"/long/1", "/none/1"} {
+		rec := serve(r, "GET", path)
+		if rec.Code != http.StatusOK || rec.Body.String() != "1" {
+			t.Errorf("GET %s = %d %q, want 200 \"1\"", path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestTimeoutMiddlewareKeepsContext(t *testing.T) {
+	r := New()
+	r.Use(TimeoutMiddleware(time.Second))
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		if Param(req, "id") != "42" {
+			t.Errorf("Param(id) = %q, want 42", Param(req, "id"))
+		}
+	})
+	if rec := serve(r, "GET", "/users/42"); rec.Code != http.StatusOK {
+		t.Errorf("GET /users/42 = %d, want 200", rec.Code)
+	}
+}
+
+func TestTimeoutAbandonsRequest(t *testing.T) {
+	gcontext.Purge(0)
+	release := make(chan struct{})
+	inner := make(chan *http.Request, 1)
+	r := New()
+	r.Persist = ContextPersist
+	r.Profile = true
+	r.Use(TimeoutMiddleware(20 * time.Millisecond))
+	var timings map[string]time.Duration
+	r.AfterResponse(func(req *http.Request, info ResponseInfo) {
+		timings = Timings(req)
+	})
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		inner <- req
+		<-release
+		gcontext.Set(req, "late", true)
+	})
+
+	if rec := serve(r, "GET", "/users/42"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /users/42 = %d, want 503", rec.Code)
+	}
+	if _, ok := timings["handler"]; ok {
+		t.Errorf("timings = %v, recorded the abandoned handler", timings)
+	}
+	close(release)
+	req := <-inner
+	for start := time.Now(); gcontext.GetAll(req) != nil && time.Since(start) < time.Second; {
+		time.Sleep(time.Millisecond)
+	}
+	if n := gcontext.Purge(0); n != 0 {
+		t.Errorf("%d requests left in gorilla context after the timeout, want 0", n)
+	}
+}
+
+func TestTimeoutMiddlewareNotInstalled(t *testing.T) {
+	r := New()
+	r.GETMeta("/x", map[string]interface{}{MetaTimeout: "1ms"}, func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	})
+	if rec := serve(r, "GET", "/x"); rec.Code != http.StatusOK {
+		t.Errorf("GET /x = %d, want 200", rec.Code)
+	}
+}
+
+func TestInvalidTimeout(t *testing.T) {
+	for _, v := range []interface{}{"soon", 5} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("timeout %v: expected panic", v)
+				}
+			}()
+			New().GETMeta("/x", map[string]interface{}{MetaTimeout: v}, func(w http.ResponseWriter, req *http.Request) {})
+		}()
+	}
+}
@@ -0,0 +1,222 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/*
+Registers a GET route that only matches path exactly. The trailing-slash
+variant of path is not redirected, regardless of RedirectTrailingSlash, and
+is served by the NotFound handler instead. Since the redirect setting is
+global in httprouter, this is done by registering the sibling path, so the
+sibling cannot be registered as a route of its own.
+
+	r.GETExact("/api/users", ListUsers) // "/api/users/" is a 404
+*/
+func (r *Router) GETExact(path string, fn http.HandlerFunc) *Route {
+	return r.GETMeta(path, map[string]interface{}{MetaTrailingSlash: TrailingSlashStrict}, fn)
+}
+
+/*
+Registers a GET route that serves both path and its trailing-slash variant
+directly, without a redirect. A variant that is already registered is left
+alone.
+
+	r.GETWithSlash("/about", ShowAbout) // "/about/" is a 200 too
+*/
+func (r *Router) GETWithSlash(path string, fn http.HandlerFunc) *Route {
+	return r.GETMeta(path, map[string]interface{}{MetaTrailingSlash: TrailingSlashAlias}, fn)
+}
+
+/*
+The metadata key that selects the trailing-slash handling of a single route,
+and its values. With TrailingSlashStrict the trailing-slash variant of the
+path is a 404 even when RedirectTrailingSlash is set; with
+TrailingSlashRedirect it is redirected to the route even when
+RedirectTrailingSlash is unset; with TrailingSlashAlias it is served by the
+route's handler. Routes without the key follow the global
+RedirectTrailingSlash setting. Any value reserves the variant path, so it
+cannot be registered as a route of its own. Strict and redirect variants are
+not tracked as routes, so they are not listed by HTMLRoutesHandler or
+counted as routes by the metrics, and a strict variant is left out of the
+methods reported for its path.
+*/
+const (
+	MetaTrailingSlash     = "trailingSlash"
+	TrailingSlashStrict   = "strict"
+	TrailingSlashRedirect = "redirect"
+	TrailingSlashAlias    = "alias"
+)
+
+/*
+Registers a GET route with metadata attached, see HandleMeta.
+
+	r.GETMeta("/reports", map[string]interface{}{"trailingSlash": "strict"}, ListReports)
+*/
+func (r *Router) GETMeta(path string, meta map[string]interface{}, fn http.HandlerFunc) *Route {
+	return r.HandleMeta("GET", path, meta, fn)
+}
+
+/*
+Registers the trailing-slash variant of a route according to the
+MetaTrailingSlash metadata of the route.
+*/
+func (r *Router) handleTrailingSlash(rt *Route, fn http.HandlerFunc) {
+	mode, _ := rt.Meta[MetaTrailingSlash].(string)
+	sibling := trailingSlashSibling(rt.Path)
+	if sibling == "" {
+		return
+	}
+	switch mode {
+	case TrailingSlashStrict:
+		r.handleSibling(rt.Method, sibling, true, func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+			r.notFound(w, req)
+		})
+	case TrailingSlashRedirect:
+		r.handleSibling(rt.Method, sibling, false, func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+			code := http.StatusMovedPermanently
+			if req.Method != http.MethodGet {
+				code = http.StatusPermanentRedirect
+			}
+			u := *req.URL
+			u.Path = toggleTrailingSlash(u.Path)
+			if u.RawPath != "" {
+				u.RawPath = toggleTrailingSlash(u.RawPath)
+			}
+			http.Redirect(w, req, u.String(), code)
+		})
+	case TrailingSlashAlias:
+		if !r.hasRoute(rt.Method, sibling) {
+			r.handle(rt.Method, sibling, rt.Meta, rt.middleware, fn)
+		}
+	}
+}
+
+/*
+Registers h for the trailing-slash variant path of a route without tracking
+it as a route. A reserved variant is also left out of allowedMethods, since
+it only exists to answer with a 404.
+*/
+func (r *Router) handleSibling(method, path string, reserved bool, h httprouter.Handle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Router.Handle(method, path, h)
+	if reserved {
+		r.reserved = append(r.reserved, &Route{Method: method, Path: path})
+	}
+}
+
+/*
+Reports whether method and path are served by a reserved trailing-slash
+variant, given the params httprouter matched.
+*/
+func (r *Router) isReserved(method, path string, ps httprouter.Params) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rt := range r.reserved {
+		if rt.Method == method && expandPattern(rt.Path, ps.ByName) == path {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Reports whether a route is registered for exactly method and path.
+*/
+func (r *Router) hasRoute(method, path string) bool {
+	for _, rt := range r.routeList() {
+		if rt.Method == method && rt.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Serves req with the router's NotFound handler, or http.NotFound if there is
+none.
+*/
+func (r *Router) notFound(w http.ResponseWriter, req *http.Request) {
+	if r.NotFound != nil {
+		r.NotFound.ServeHTTP(w, req)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+/*
+Returns path with its trailing slash added or removed, or an empty string
+for paths that have no such variant.
+*/
+func trailingSlashSibling(path string) string {
+	switch {
+	case path == "/" || strings.Contains(path, "*"):
+		return ""
+	case strings.HasSuffix(path, "/"):
+		return strings.TrimSuffix(path, "/")
+	default:
+		return path + "/"
+	}
+}
+
+/*
+Returns the concrete request path with its trailing slash removed, or added
+if it has none.
+*/
+func toggleTrailingSlash(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path + "/"
+}
+
+/*
+Returns a middleware that redirects GET and HEAD requests whose path contains
+repeated slashes, e.g. "/a//b", to the canonical path "/a/b" with a 301 Moved
+Permanently. The query string is preserved. Requests with other methods and
+requests with a canonical path pass through unchanged.
+*/
+func CanonicalPathMiddleware() func(http.Handler) http.Handler {
+	return canonicalPathMiddleware(false)
+}
+
+/*
+Returns a middleware like CanonicalPathMiddleware that also treats a trailing
+slash as non-canonical, so "/a//b/" is redirected to "/a/b". The root path
+"/" is left alone.
+*/
+func CanonicalPathMiddlewareWithTrailingSlash() func(http.Handler) http.Handler {
+	return canonicalPathMiddleware(true)
+}
+
+func canonicalPathMiddleware(trimTrailingSlash bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				next.ServeHTTP(w, req)
+				return
+			}
+			path := req.URL.EscapedPath()
+			clean := path
+			for strings.Contains(clean, "//") {
+				clean = strings.ReplaceAll(clean, "//", "/")
+			}
+			if trimTrailingSlash && len(clean) > 1 {
+				clean = strings.TrimSuffix(clean, "/")
+			}
+			if clean == path {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if req.URL.RawQuery != "" {
+				clean += "?" + req.URL.RawQuery
+			}
+			http.Redirect(w, req, clean, http.StatusMovedPermanently)
+		})
+	}
+}
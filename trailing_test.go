@@ -0,0 +1,165 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serve(r http.Handler, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestGETExact(t *testing.T) {
+	r := New()
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.GETExact("/x", ok)
+	r.GET("/y", ok)
+
+	if rec := serve(r, "GET", "/x"); rec.Code != http.StatusOK {
+		t.Errorf("GET /x = %d, want 200", rec.Code)
+	}
+	if rec := serve(r, "GET", "/x/"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /x/ = %d, want 404", rec.Code)
+	}
+	if rec := serve(r, "GET", "/y/"); rec.Code != http.StatusMovedPermanently {
+		t.Errorf("GET /y/ = %d, want 301", rec.Code)
+	}
+}
+
+func TestGETExactSiblingIsNotARoute(t *testing.T) {
+	r := New()
+	var label string
+	r.Use(r.MetricsMiddleware(func(route string, status int, d time.Duration) { label = route }))
+	r.GETExact("/x", func(w http.ResponseWriter, req *http.Request) {})
+
+	rec := serve(r, "POST", "/x/")
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Allow") != "" {
+		t.Errorf("POST /x/ = %d with Allow %q, want 404 without Allow", rec.Code, rec.Header().Get("Allow"))
+	}
+	if rec := serve(r, "POST", "/x"); rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("POST /x = %d with Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+	for _, rt := range r.routeList() {
+		if rt.Path == "/x/" {
+			t.Errorf("sibling /x/ is tracked as a route")
+		}
+	}
+	serve(r, "GET", "/x/")
+	if label != UnmatchedRouteLabel {
+		t.Errorf("metrics label of /x/ = %q, want %q", label, UnmatchedRouteLabel)
+	}
+}
+
+func TestTrailingSlashMeta(t *testing.T) {
+	r := New()
+	r.RedirectTrailingSlash = false
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.GETMeta("/strict/:id", map[string]interface{}{MetaTrailingSlash: TrailingSlashStrict}, ok)
+	r.GETMeta("/users/:id", map[string]interface{}{MetaTrailingSlash: TrailingSlashRedirect}, ok)
+	r.GETMeta("/posts/:id/", map[string]interface{}{MetaTrailingSlash: TrailingSlashRedirect}, ok)
+
+	if rec := serve(r, "GET", "/strict/42/"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /strict/42/ = %d, want 404", rec.Code)
+	}
+	if rec := serve(r, "GET", "/strict/42"); rec.Code != http.StatusOK {
+		t.Errorf("GET /strict/42 = %d, want 200", rec.Code)
+	}
+
+	tests := map[string]string{
+		"/users/42/?tab=posts": "/users/42?tab=posts",
+		"/posts/7":             "/posts/7/",
+		"/users/a%20b/":        "/users/a%20b",
+	}
+	for path, want := range tests {
+		rec := serve(r, "GET", path)
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != want {
+			t.Errorf("GET %s = %d to %q, want 301 to %q", path, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+}
+
+func TestCanonicalPathMiddleware(t *testing.T) {
+	r := New()
+	r.Use(CanonicalPathMiddleware())
+	r.GET("/a/b", func(w http.ResponseWriter, req *http.Request) {})
+	r.POST("/a/b", func(w http.ResponseWriter, req *http.Request) {})
+
+	rec := serve(r, "GET", "/a//b?x=1")
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/a/b?x=1" {
+		t.Errorf("GET /a//b?x=1 = %d %q, want 301 /a/b?x=1", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := serve(r, "GET", "/a/b"); rec.Code != http.StatusOK {
+		t.Errorf("GET /a/b = %d, want 200", rec.Code)
+	}
+	if rec := serve(r, "POST", "/a//b"); rec.Code == http.StatusMovedPermanently {
+		t.Errorf("POST /a//b was redirected")
+	}
+}
+
+func TestCanonicalPathMiddlewareWithTrailingSlash(t *testing.T) {
+	r := New()
+	r.Use(CanonicalPathMiddlewareWithTrailingSlash())
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.GET("/", ok)
+	r.GET("/a/b", ok)
+
+	for path, want := range map[string]string{"/a//b/": "/a/b", "/a/b/": "/a/b"} {
+		rec := serve(r, "GET", path)
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != want {
+			t.Errorf("GET %s = %d %q, want 301 %s", path, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+	for _, path := range []string{"/", "/a/b"} {
+		if rec := serve(r, "GET", path); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", path, rec.Code)
+		}
+	}
+}
+
+func TestGETWithSlash(t *testing.T) {
+	r := New()
+	r.GETWithSlash("/about", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("about"))
+	})
+	r.GETWithSlash("/docs/", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("docs"))
+	})
+	r.GETWithSlash("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("user " + Param(req, "id")))
+	})
+
+	for path, want := range map[string]string{
+		"/about":     "about",
+		"/about/":    "about",
+		"/docs":      "docs",
+		"/docs/":     "docs",
+		"/users/42":  "user 42",
+		"/users/42/": "user 42",
+	} {
+		rec := serve(r, "GET", path)
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("GET %s = %d %q, want 200 %q", path, rec.Code, rec.Body.String(), want)
+		}
+	}
+}
+
+func TestGETWithSlashKeepsRegisteredVariant(t *testing.T) {
+	r := New()
+	r.GET("/about/", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("explicit"))
+	})
+	r.GETWithSlash("/about", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("about"))
+	})
+
+	if rec := serve(r, "GET", "/about/"); rec.Body.String() != "explicit" {
+		t.Errorf("GET /about/ = %q, want the explicitly registered route", rec.Body.String())
+	}
+	if rec := serve(r, "GET", "/about"); rec.Body.String() != "about" {
+		t.Errorf("GET /about = %q, want about", rec.Body.String())
+	}
+}
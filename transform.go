@@ -0,0 +1,134 @@
+package httprouterpersist
+
+import (
+	"bufio"
+	"bytes"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+The largest JSON response body, in bytes, that SetResponseTransform buffers.
+Larger responses are streamed unchanged.
+*/
+const MaxTransformBody = 1 << 20
+
+/*
+Sets a transform that is applied to every JSON response before it is sent.
+fn receives the status and the complete body written by the handler and
+returns the status and body to send instead. Responses to HEAD requests,
+responses without a JSON Content-Type, bodiless and empty responses, flushed
+responses and responses larger than MaxTransformBody are sent untransformed.
+
+	r.SetResponseTransform(func(status int, body []byte) (int, []byte) {
+		return status, []byte(`{"data":` + string(bytes.TrimSpace(body)) + `}`)
+	})
+*/
+func (r *Router) SetResponseTransform(fn func(status int, body []byte) (int, []byte)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transform = fn
+}
+
+type transformWriter struct {
+	http.ResponseWriter
+	transform   func(int, []byte) (int, []byte)
+	status      int
+	buf         bytes.Buffer
+	wroteHeader bool
+	passthrough bool
+}
+
+func (w *transformWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+	if code == http.StatusNoContent || code == http.StatusNotModified || !isJSON(w.Header().Get("Content-Type")) {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *transformWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() > MaxTransformBody {
+		if err := w.stream(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+/*
+Stops buffering and sends the response untransformed from then on.
+*/
+func (w *transformWriter) Flush() {
+	w.flush()
+}
+
+func (w *transformWriter) flush() bool {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.passthrough {
+		w.stream()
+	}
+	return Flush(w.ResponseWriter)
+}
+
+func (w *transformWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+/*
+Hijacks the connection. Nothing is buffered or sent from then on.
+*/
+func (w *transformWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	w.passthrough = true
+	return hijack(w.ResponseWriter)
+}
+
+func (w *transformWriter) stream() error {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+/*
+Sends the transformed buffered response, if there is one.
+*/
+func (w *transformWriter) finish() {
+	if !w.wroteHeader || w.passthrough {
+		return
+	}
+	if w.buf.Len() == 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+		return
+	}
+	status, body := w.transform(w.status, w.buf.Bytes())
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(status)
+	w.ResponseWriter.Write(body)
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,94 @@
+package httprouterpersist
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func envelopeRouter() *Router {
+	r := New()
+	r.SetResponseTransform(func(status int, body []byte) (int, []byte) {
+		return status, []byte(`{"data":` + string(bytes.TrimSpace(body)) + `}`)
+	})
+	return r
+}
+
+func TestResponseTransformEnvelope(t *testing.T) {
+	r := envelopeRouter()
+	r.GET("/json", func(w http.ResponseWriter, req *http.Request) {
+		WriteJSON(w, http.StatusCreated, map[string]int{"id": 1})
+	})
+	r.GET("/text", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello"))
+	})
+
+	rec := serve(r, "GET", "/json")
+	if rec.Code != http.StatusCreated || rec.Body.String() != `{"data":{"id":1}}` {
+		t.Errorf("json = %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Length") != "17" {
+		t.Errorf("Content-Length = %q", rec.Header().Get("Content-Length"))
+	}
+	if rec := serve(r, "GET", "/text"); rec.Body.String() != "hello" {
+		t.Errorf("text = %q", rec.Body.String())
+	}
+}
+
+func TestResponseTransformSkipsHeadAndEmpty(t *testing.T) {
+	r := envelopeRouter()
+	json := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+	}
+	r.HEAD("/head", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	})
+	r.GET("/empty", json)
+
+	rec := serve(r, "HEAD", "/head")
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Length") != "8" {
+		t.Errorf("HEAD = %q with Content-Length %q", rec.Body.String(), rec.Header().Get("Content-Length"))
+	}
+	rec = serve(r, "GET", "/empty")
+	if rec.Code != http.StatusAccepted || rec.Body.Len() != 0 || rec.Header().Get("Content-Length") != "" {
+		t.Errorf("empty = %d %q with Content-Length %q", rec.Code, rec.Body.String(), rec.Header().Get("Content-Length"))
+	}
+}
+
+func TestResponseTransformStreamsLargeBodies(t *testing.T) {
+	r := envelopeRouter()
+	large := `"` + strings.Repeat("x", MaxTransformBody) + `"`
+	r.GET("/large", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(large))
+	})
+	if rec := serve(r, "GET", "/large"); rec.Body.String() != large {
+		t.Errorf("large body was transformed")
+	}
+}
+
+func TestResponseTransformPanic(t *testing.T) {
+	r := envelopeRouter()
+	r.GET("/panic", func(w http.ResponseWriter, req *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]int{"id": 1})
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want boom", p)
+			}
+		}()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", "/panic", nil))
+	}()
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Length") != "" {
+		t.Errorf("partial body sent after panic: %q with Content-Length %q", rec.Body.String(), rec.Header().Get("Content-Length"))
+	}
+}
@@ -0,0 +1,67 @@
+package httprouterpersist
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
+
+/*
+The validators of the param types supported by GETTyped.
+*/
+var paramTypes = map[string]func(string) bool{
+	"int": func(v string) bool {
+		_, err := strconv.Atoi(v)
+		return err == nil
+	},
+	"uuid": uuidPattern.MatchString,
+	"slug": slugPattern.MatchString,
+}
+
+/*
+Registers a GET route whose pattern declares the types of its params inline,
+as in "/users/:id{int}". Supported types are int, uuid and slug. The route is
+registered without the annotations and requests with a param that is not of
+its type are rejected according to the router's ConstraintFailureMode, which
+answers with a 404 by default. Int params can be read with ParamInt.
+Unknown types panic at registration.
+
+	r.GETTyped("/users/:id{int}/posts/:slug{slug}", ShowPost)
+*/
+func (r *Router) GETTyped(pattern string, fn http.HandlerFunc) *Route {
+	path, constraints := parseTypedPattern(pattern)
+	return r.GET(path, r.constrained(constraints, fn))
+}
+
+/*
+Strips the type annotations from pattern and returns the constraints they
+declare.
+*/
+func parseTypedPattern(pattern string) (string, []paramConstraint) {
+	var constraints []paramConstraint
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		open := strings.IndexByte(seg, '{')
+		if open < 0 {
+			continue
+		}
+		if !strings.HasPrefix(seg, ":") || !strings.HasSuffix(seg, "}") || open == 1 {
+			panic(fmt.Sprintf("httprouterpersist: invalid type annotation '%s' in path '%s'", seg, pattern))
+		}
+		param, typ := seg[1:open], seg[open+1:len(seg)-1]
+		valid, ok := paramTypes[typ]
+		if !ok {
+			panic(fmt.Sprintf("httprouterpersist: unknown param type '%s' in path '%s'", typ, pattern))
+		}
+		constraints = append(constraints, paramConstraint{param, typ, valid})
+		segments[i] = seg[:open]
+	}
+	return strings.Join(segments, "/"), constraints
+}
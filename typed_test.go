@@ -0,0 +1,43 @@
+package httprouterpersist
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGETTyped(t *testing.T) {
+	r := New()
+	r.GETTyped("/users/:id{int}", func(w http.ResponseWriter, req *http.Request) {
+		if _, err := ParamInt(req, "id"); err != nil {
+			t.Errorf("ParamInt(id): %v", err)
+		}
+	})
+	r.GETTyped("/orders/:id{uuid}", func(w http.ResponseWriter, req *http.Request) {})
+	r.GETTyped("/posts/:slug{slug}", func(w http.ResponseWriter, req *http.Request) {})
+
+	for path, want := range map[string]int{
+		"/users/42":  http.StatusOK,
+		"/users/4x2": http.StatusNotFound,
+		"/orders/3f2504e0-4f89-11d3-9a0c-0305e82c3301": http.StatusOK,
+		"/orders/3f2504e0":    http.StatusNotFound,
+		"/posts/hello-world":  http.StatusOK,
+		"/posts/Hello--World": http.StatusNotFound,
+	} {
+		if rec := serve(r, "GET", path); rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
+func TestGETTypedInvalid(t *testing.T) {
+	for _, pattern := range []string{"/users/:id{float}", "/users/id{int}", "/users/:{int}"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", pattern)
+				}
+			}()
+			New().GETTyped(pattern, func(w http.ResponseWriter, req *http.Request) {})
+		}()
+	}
+}
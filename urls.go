@@ -0,0 +1,104 @@
+package httprouterpersist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/*
+Returns the path of the route registered under name with its params
+replaced by the values in params. Values are path escaped. Only the value of
+a catch-all param may contain slashes, which are kept; a slash in the value
+of a named param is an error, since the url would not route back to the
+route.
+
+	r.GET("/users/:id", ShowUser).Named("user")
+	path, err := r.URL("user", map[string]string{"id": "42"}) // "/users/42"
+*/
+func (r *Router) URL(name string, params map[string]string) (string, error) {
+	var rt *Route
+	for _, candidate := range r.routeList() {
+		if candidate.Name == name {
+			rt = candidate
+			break
+		}
+	}
+	if rt == nil {
+		return "", fmt.Errorf("httprouterpersist: no route named %q", name)
+	}
+
+	names, err := patternParams(rt.Path)
+	if err != nil {
+		return "", fmt.Errorf("httprouterpersist: route %q: %v", name, err)
+	}
+	catchAll := ""
+	if i := strings.IndexByte(rt.Path, '*'); i >= 0 {
+		catchAll = rt.Path[i+1:]
+	}
+	for _, n := range names {
+		v, ok := params[n]
+		if !ok {
+			return "", fmt.Errorf("httprouterpersist: route %q: missing param %q", name, n)
+		}
+		if n != catchAll && strings.Contains(v, "/") {
+			return "", fmt.Errorf("httprouterpersist: route %q: param %q contains a slash", name, n)
+		}
+	}
+	return expandPattern(rt.Path, func(n string) string {
+		if n != catchAll {
+			return url.PathEscape(params[n])
+		}
+		segments := strings.Split(params[n], "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		return strings.Join(segments, "/")
+	}), nil
+}
+
+/*
+Returns the absolute url of the route registered under name, using the scheme
+and host of r. The scheme is taken from the X-Forwarded-Proto header when a
+proxy set it to http or https. r must have been served by the router. An error is returned
+when r carries no host.
+
+	link, err := router.AbsoluteURL(r, "user", map[string]string{"id": "42"})
+	// "https://api.example.com/users/42"
+*/
+func AbsoluteURL(r *http.Request, routeName string, params map[string]string) (string, error) {
+	s := stateFrom(r)
+	if s == nil || s.router == nil {
+		return "", errors.New("httprouterpersist: request was not served by a router")
+	}
+	path, err := s.router.URL(routeName, params)
+	if err != nil {
+		return "", err
+	}
+
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	if host == "" {
+		return "", errors.New("httprouterpersist: request has no host")
+	}
+	return requestScheme(r) + "://" + host + path, nil
+}
+
+/*
+Returns the scheme the client used to make r. An X-Forwarded-Proto header is
+only trusted when it names http or https; any other value is ignored.
+*/
+func requestScheme(r *http.Request) string {
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	if proto = strings.ToLower(strings.TrimSpace(proto)); proto == "http" || proto == "https" {
+		return proto
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
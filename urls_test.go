@@ -0,0 +1,121 @@
+package httprouterpersist
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	r := New()
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.GET("/users/:id", ok).Named("user")
+	r.GET("/files/*path", ok).Named("file")
+	r.GET("/user_:name", ok).Named("profile")
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   string
+	}{
+		{"user", map[string]string{"id": "42"}, "/users/42"},
+		{"user", map[string]string{"id": "a b?c"}, "/users/a%20b%3Fc"},
+		{"file", map[string]string{"path": "docs/read me.txt"}, "/files/docs/read%20me.txt"},
+		{"profile", map[string]string{"name": "bob"}, "/user_bob"},
+	}
+	for _, tt := range tests {
+		got, err := r.URL(tt.name, tt.params)
+		if err != nil || got != tt.want {
+			t.Errorf("URL(%q, %v) = %q, %v, want %q", tt.name, tt.params, got, err, tt.want)
+		}
+	}
+	if _, err := r.URL("user", nil); err == nil {
+		t.Error("missing param did not error")
+	}
+	if _, err := r.URL("user", map[string]string{"id": "a/b"}); err == nil {
+		t.Error("slash in a named param did not error")
+	}
+	if _, err := r.URL("nope", nil); err == nil {
+		t.Error("unknown name did not error")
+	}
+}
+
+func TestURLRoutesBack(t *testing.T) {
+	r := New()
+	var got string
+	r.GET("/users/:id/posts", func(w http.ResponseWriter, req *http.Request) {
+		got = Param(req, "id")
+	}).Named("posts")
+
+	path, _ := r.URL("posts", map[string]string{"id": "a b?c#d"})
+	serve(r, "GET", path)
+	if got != "a b?c#d" {
+		t.Errorf("param routed back from %q = %q", path, got)
+	}
+}
+
+func TestURLCatchAllRoutesBack(t *testing.T) {
+	r := New()
+	var got string
+	r.GET("/files/*path", func(w http.ResponseWriter, req *http.Request) {
+		got = Param(req, "path")
+	}).Named("file")
+
+	path, err := r.URL("file", map[string]string{"path": "docs/a b/c.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(r, "GET", path)
+	if got != "/docs/a b/c.txt" {
+		t.Errorf("param routed back from %q = %q", path, got)
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*http.Request)
+		want  string
+	}{
+		{"plain", func(*http.Request) {}, "http://api.example.com/users/42"},
+		{"tls", func(req *http.Request) { req.TLS = &tls.ConnectionState{} }, "https://api.example.com/users/42"},
+		{"forwarded", func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "HTTPS, http") }, "https://api.example.com/users/42"},
+		{"bogus forwarded", func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "javascript:alert(1)//") }, "http://api.example.com/users/42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			var got string
+			var err error
+			r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+				got, err = AbsoluteURL(req, "user", map[string]string{"id": Param(req, "id")})
+			}).Named("user")
+
+			req := httptest.NewRequest("GET", "http://api.example.com/users/42", nil)
+			tt.setup(req)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			if err != nil || got != tt.want {
+				t.Errorf("AbsoluteURL = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbsoluteURLErrors(t *testing.T) {
+	if _, err := AbsoluteURL(httptest.NewRequest("GET", "/", nil), "user", nil); err == nil {
+		t.Error("request not served by a router did not error")
+	}
+
+	r := New()
+	var err error
+	r.GET("/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		_, err = AbsoluteURL(req, "user", map[string]string{"id": "1"})
+	}).Named("user")
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Host = ""
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if err == nil {
+		t.Error("request without host did not error")
+	}
+}
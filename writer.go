@@ -0,0 +1,250 @@
+package httprouterpersist
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+/*
+The statusWriter type wraps an http.ResponseWriter and records the status
+code and the number of body bytes written by a handler.
+*/
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+/*
+Returns the wrapped http.ResponseWriter, allowing http.ResponseController to
+reach the underlying writer.
+*/
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusWriter) Flush() {
+	w.flush()
+}
+
+func (w *statusWriter) flush() bool {
+	w.wroteHeader = true
+	return Flush(w.ResponseWriter)
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijack(w.ResponseWriter)
+}
+
+/*
+The statusInterceptor type hands the response to a registered status
+handler when the wrapped handler writes a matching status code, and discards
+the wrapped handler's body from then on.
+*/
+type statusInterceptor struct {
+	http.ResponseWriter
+	req         *http.Request
+	handlers    map[int]http.HandlerFunc
+	wroteHeader bool
+	intercepted bool
+}
+
+func (w *statusInterceptor) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h, ok := w.handlers[code]
+	if !ok {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.intercepted = true
+	header := w.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	header.Del("X-Content-Type-Options")
+
+	cw := &codeWriter{ResponseWriter: w.ResponseWriter, code: code}
+	h(cw, w.req)
+	if !cw.wroteHeader {
+		cw.WriteHeader(code)
+	}
+}
+
+func (w *statusInterceptor) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.intercepted {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusInterceptor) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusInterceptor) Flush() {
+	w.flush()
+}
+
+func (w *statusInterceptor) flush() bool {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return Flush(w.ResponseWriter)
+}
+
+func (w *statusInterceptor) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijack(w.ResponseWriter)
+}
+
+/*
+The codeWriter type writes code as the status unless the wrapped handler
+writes a status of its own first.
+*/
+type codeWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *codeWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *codeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(w.code)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *codeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *codeWriter) Flush() {
+	w.flush()
+}
+
+func (w *codeWriter) flush() bool {
+	if !w.wroteHeader {
+		w.WriteHeader(w.code)
+	}
+	return Flush(w.ResponseWriter)
+}
+
+/*
+Flushes any buffered response data to the client and reports whether it
+could. Writers wrapped by the package middleware are unwrapped until one that
+implements http.Flusher is found, so long-polling handlers can flush through
+the logging, gzip and other wrappers. The package wrappers report whether the
+writer they wrap could flush, so Flush returns false when nothing reached the
+client:
+
+	fmt.Fprint(w, event)
+	router.Flush(w)
+*/
+func Flush(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case interface{ flush() bool }:
+			return t.flush()
+		case http.Flusher:
+			t.Flush()
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+/*
+Hijacks the connection of w. Writers wrapped by the package middleware are
+unwrapped until one that implements http.Hijacker is found, so that
+WebSocket upgrades work through the logging, gzip and other wrappers.
+Returns http.ErrNotSupported if none does.
+*/
+func hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	for {
+		switch t := w.(type) {
+		case http.Hijacker:
+			return t.Hijack()
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return nil, nil, http.ErrNotSupported
+		}
+	}
+}
+
+/*
+Wraps a HEAD handler so that it cannot send a body. Body writes are counted
+and discarded, and the header is sent once the handler returns, so that a
+handler writing the body it would send for GET produces the matching
+Content-Length.
+*/
+func suppressBody(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		hw := &headWriter{ResponseWriter: w, status: http.StatusOK}
+		fn(hw, req)
+		hw.finish()
+	}
+}
+
+type headWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (w *headWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+}
+
+func (w *headWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.bytes += len(b)
+	return len(b), nil
+}
+
+func (w *headWriter) finish() {
+	h := w.Header()
+	if w.bytes > 0 && h.Get("Content-Length") == "" && h.Get("Transfer-Encoding") == "" {
+		h.Set("Content-Length", strconv.Itoa(w.bytes))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
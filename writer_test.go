@@ -0,0 +1,187 @@
+package httprouterpersist
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+/*
+Registers /flush and /hijack on r and asserts that handlers behind r's
+wrappers can still flush and hijack.
+*/
+func assertFlushAndHijack(t *testing.T, r *Router) {
+	t.Helper()
+	r.GET("/flush", func(w http.ResponseWriter, req *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Errorf("%T does not implement http.Flusher", w)
+			return
+		}
+		w.Write([]byte("partial"))
+		f.Flush()
+	})
+	r.GET("/hijack", func(w http.ResponseWriter, req *http.Request) {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			t.Errorf("%T does not implement http.Hijacker", w)
+			return
+		}
+		conn, buf, err := h.Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		buf.Flush()
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/flush", nil))
+	if !rec.Flushed {
+		t.Error("flush did not reach the recorder")
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/hijack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if string(body) != "hijacked" {
+		t.Errorf("hijacked body = %q", body)
+	}
+}
+
+func TestWrappersKeepFlushAndHijack(t *testing.T) {
+	id := func(h http.Handler) http.Handler { return h }
+	tests := map[string]func(r *Router){
+		"status":    func(r *Router) { r.OnStatus(http.StatusTeapot, func(http.ResponseWriter, *http.Request) {}) },
+		"transform": func(r *Router) { r.SetResponseTransform(func(s int, b []byte) (int, []byte) { return s, b }) },
+		"gzip":      func(r *Router) { r.Use(GzipMiddleware()) },
+		"json":      func(r *Router) { r.Use(EnforceJSONMiddleware()) },
+		"checksum":  func(r *Router) { r.Use(ChecksumTrailerMiddleware("X-Checksum")) },
+		"logging":   func(r *Router) { r.Use(CLFLogMiddleware(io.Discard)) },
+		"metrics":   func(r *Router) { r.Use(r.StatsMiddleware()) },
+		"plain":     func(r *Router) { r.Use(id) },
+	}
+	for name, setup := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := New()
+			setup(r)
+			assertFlushAndHijack(t, r)
+		})
+	}
+}
+
+func TestHijackNotSupported(t *testing.T) {
+	w := newStatusWriter(struct{ http.ResponseWriter }{httptest.NewRecorder()})
+	if _, _, err := w.Hijack(); err != http.ErrNotSupported {
+		t.Errorf("err = %v, want http.ErrNotSupported", err)
+	}
+}
+
+/*
+The unwrapOnly type wraps a writer without implementing http.Flusher, like
+third-party wrappers that only expose Unwrap.
+*/
+type unwrapOnly struct {
+	http.ResponseWriter
+}
+
+func (w unwrapOnly) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := New()
+	r.Use(r.StatsMiddleware(), GzipMiddleware(), func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(unwrapOnly{w}, req)
+		})
+	})
+	r.GET("/poll", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("event: 1\n"))
+		if !Flush(w) {
+			t.Error("Flush(w) = false")
+		}
+		if !rec.Flushed || rec.Body.Len() == 0 {
+			t.Errorf("recorder not flushed: Flushed=%v, %d bytes", rec.Flushed, rec.Body.Len())
+		}
+	})
+
+	req := httptest.NewRequest("GET", "/poll", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+
+	if Flush(unwrapOnly{struct{ http.ResponseWriter }{httptest.NewRecorder()}}) {
+		t.Error("Flush reported success for a writer chain without a Flusher")
+	}
+}
+
+func TestFlushWithoutFlusher(t *testing.T) {
+	tests := map[string]func(r *Router){
+		"status":    func(r *Router) { r.OnStatus(http.StatusTeapot, func(http.ResponseWriter, *http.Request) {}) },
+		"transform": func(r *Router) { r.SetResponseTransform(func(s int, b []byte) (int, []byte) { return s, b }) },
+		"gzip":      func(r *Router) { r.Use(GzipMiddleware()) },
+		"json":      func(r *Router) { r.Use(EnforceJSONMiddleware()) },
+		"checksum":  func(r *Router) { r.Use(ChecksumTrailerMiddleware("X-Checksum")) },
+		"coalesce":  func(r *Router) { r.Use(CoalesceMiddleware()) },
+		"logging":   func(r *Router) { r.Use(CLFLogMiddleware(io.Discard)) },
+	}
+	for name, setup := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := New()
+			setup(r)
+			called := false
+			r.GET("/poll", func(w http.ResponseWriter, req *http.Request) {
+				called = true
+				w.Write([]byte("event: 1\n"))
+				if Flush(w) {
+					t.Errorf("Flush(%T) = true over a writer that cannot flush", w)
+				}
+			})
+
+			req := httptest.NewRequest("GET", "/poll", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			r.ServeHTTP(struct{ http.ResponseWriter }{httptest.NewRecorder()}, req)
+			if !called {
+				t.Error("handler not called")
+			}
+		})
+	}
+}
+
+func TestHEADSuppressesBody(t *testing.T) {
+	r := New()
+	r.HEAD("/files/:name", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello "))
+		w.Write([]byte(Param(req, "name")))
+	})
+	r.HEAD("/sized", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", "1024")
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	rec := serve(r, "HEAD", "/files/world")
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("HEAD /files/world = %d %q, want 200 with an empty body", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Length"); got != "11" {
+		t.Errorf("HEAD /files/world Content-Length = %q, want 11", got)
+	}
+
+	rec = serve(r, "HEAD", "/sized")
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Content-Length") != "1024" {
+		t.Errorf("HEAD /sized = %d Content-Length %q, want 202 and 1024", rec.Code, rec.Header().Get("Content-Length"))
+	}
+}
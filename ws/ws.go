@@ -0,0 +1,61 @@
+/*
+The ws package upgrades requests served by an httprouterpersist router to
+WebSocket connections. It lives in its own package so that only programs
+using WebSockets depend on gorilla/websocket.
+
+The handler gets the upgraded connection together with the original request,
+so the route params remain available:
+
+	r.GET("/ws/:room", ws.WebSocketHandler(nil, func(c *websocket.Conn, req *http.Request) {
+		room := router.Param(req, "room")
+		...
+	}))
+*/
+package ws
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+/*
+Returns a handler that upgrades the request to a WebSocket connection with
+upgrader and calls fn with the connection and the request. The connection is
+closed when fn returns. If the upgrade fails the upgrader writes the error
+response and fn is not called. A nil upgrader uses the gorilla/websocket
+defaults, which only accept same-origin requests.
+
+Response writers wrapped by middleware that do not implement http.Hijacker
+are unwrapped through their Unwrap method until one that does is found.
+*/
+func WebSocketHandler(upgrader *websocket.Upgrader, fn func(*websocket.Conn, *http.Request)) http.HandlerFunc {
+	if upgrader == nil {
+		upgrader = &websocket.Upgrader{}
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(hijacker(w), req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fn(conn, req)
+	}
+}
+
+/*
+Returns the outermost writer of w's Unwrap chain that implements
+http.Hijacker, or w itself if there is none.
+*/
+func hijacker(w http.ResponseWriter) http.ResponseWriter {
+	for u := w; ; {
+		if _, ok := u.(http.Hijacker); ok {
+			return u
+		}
+		next, ok := u.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return w
+		}
+		u = next.Unwrap()
+	}
+}
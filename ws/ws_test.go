@@ -0,0 +1,82 @@
+package ws
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	router "github.com/shopsmart/httprouterpersist"
+)
+
+func dialRoom(t *testing.T, r *router.Router) {
+	t.Helper()
+	r.GET("/ws/:room", WebSocketHandler(nil, func(c *websocket.Conn, req *http.Request) {
+		c.WriteMessage(websocket.TextMessage, []byte(router.Param(req, "room")))
+	}))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/lobby", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+	_, msg, err := c.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(msg) != "lobby" {
+		t.Errorf("room = %q, want lobby", msg)
+	}
+}
+
+func TestWebSocketHandlerRoomParam(t *testing.T) {
+	dialRoom(t, router.New())
+}
+
+func TestWebSocketHandlerBehindWrappers(t *testing.T) {
+	r := router.New()
+	r.AfterResponse(func(*http.Request, router.ResponseInfo) {})
+	r.OnStatus(http.StatusForbidden, func(http.ResponseWriter, *http.Request) {})
+	r.SetResponseTransform(func(s int, b []byte) (int, []byte) { return s, b })
+	r.Use(router.GzipMiddleware(), router.CLFLogMiddleware(io.Discard), r.StatsMiddleware())
+	dialRoom(t, r)
+}
+
+/*
+The plainWriter type hides the Hijacker of the writer it wraps, like a
+third-party middleware that only implements Unwrap.
+*/
+type plainWriter struct {
+	w http.ResponseWriter
+}
+
+func (p plainWriter) Header() http.Header         { return p.w.Header() }
+func (p plainWriter) Write(b []byte) (int, error) { return p.w.Write(b) }
+func (p plainWriter) WriteHeader(code int)        { p.w.WriteHeader(code) }
+func (p plainWriter) Unwrap() http.ResponseWriter { return p.w }
+
+func TestWebSocketHandlerUnwrapsToHijacker(t *testing.T) {
+	r := router.New()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(plainWriter{w}, req)
+		})
+	})
+	dialRoom(t, r)
+}
+
+func TestWebSocketHandlerUpgradeFailure(t *testing.T) {
+	r := router.New()
+	called := false
+	r.GET("/ws/:room", WebSocketHandler(nil, func(*websocket.Conn, *http.Request) { called = true }))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/ws/lobby", nil))
+	if rec.Code != http.StatusBadRequest || called {
+		t.Errorf("status = %d, handler called = %v", rec.Code, called)
+	}
+}